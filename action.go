@@ -1,6 +1,7 @@
 package insight_goclient
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -35,21 +36,54 @@ type Actions struct {
 
 // GetActions gets details of a list of all Actions
 func (client *InsightClient) GetActions() ([]*Action, error) {
-	var actions Actions
-	if err := client.get(ACTIONS_PATH, &actions); err != nil {
+	return client.GetActionsContext(context.Background())
+}
+
+// GetActionsContext gets details of a list of all Actions using the provided context
+func (client *InsightClient) GetActionsContext(ctx context.Context) ([]*Action, error) {
+	var result []*Action
+	err := client.ForEachActionContext(ctx, func(action *Action) error {
+		result = append(result, action)
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	return actions.Actions, nil
+	return result, nil
+}
+
+// ForEachAction calls fn for every Action of the account, fetching them page by page
+func (client *InsightClient) ForEachAction(fn func(action *Action) error) error {
+	return client.ForEachActionContext(context.Background(), fn)
+}
+
+// ForEachActionContext calls fn for every Action of the account, fetching them page by page using the provided
+// context. Iteration stops at the first error returned by fn; return ErrStopIteration to stop without failing
+func (client *InsightClient) ForEachActionContext(ctx context.Context, fn func(action *Action) error) error {
+	var actions Actions
+	return client.getPages(ctx, ACTIONS_PATH, &actions, func() error {
+		for _, action := range actions.Actions {
+			if err := fn(action); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // GetAction gets a specific Action from an account
 func (client *InsightClient) GetAction(actionId string) (*Action, error) {
+	return client.GetActionContext(context.Background(), actionId)
+}
+
+// GetActionContext gets a specific Action from an account using the provided context
+func (client *InsightClient) GetActionContext(ctx context.Context, actionId string) (*Action, error) {
 	var actionRequest ActionRequest
 	endpoint, err := client.getActionEndpoint(actionId)
 	if err != nil {
 		return nil, err
 	}
-	if err := client.get(endpoint, &actionRequest); err != nil {
+	if err := client.getWithContext(ctx, endpoint, &actionRequest); err != nil {
 		return nil, err
 	}
 	return actionRequest.Action, nil
@@ -57,8 +91,13 @@ func (client *InsightClient) GetAction(actionId string) (*Action, error) {
 
 // PostTag creates a new Action
 func (client *InsightClient) PostAction(action *Action) error {
+	return client.PostActionContext(context.Background(), action)
+}
+
+// PostActionContext creates a new Action using the provided context
+func (client *InsightClient) PostActionContext(ctx context.Context, action *Action) error {
 	actionRequest := ActionRequest{action}
-	resp, err := client.post(ACTIONS_PATH, actionRequest)
+	resp, err := client.postWithContext(ctx, ACTIONS_PATH, actionRequest)
 	if err != nil {
 		return err
 	}
@@ -71,12 +110,17 @@ func (client *InsightClient) PostAction(action *Action) error {
 
 // PutTag updates an existing Action
 func (client *InsightClient) PutAction(action *Action) error {
+	return client.PutActionContext(context.Background(), action)
+}
+
+// PutActionContext updates an existing Action using the provided context
+func (client *InsightClient) PutActionContext(ctx context.Context, action *Action) error {
 	actionRequest := ActionRequest{action}
 	endpoint, err := client.getActionEndpoint(action.Id)
 	if err != nil {
 		return err
 	}
-	resp, err := client.put(endpoint, actionRequest)
+	resp, err := client.putWithContext(ctx, endpoint, actionRequest)
 	if err != nil {
 		return err
 	}
@@ -89,11 +133,16 @@ func (client *InsightClient) PutAction(action *Action) error {
 
 // DeleteTag deletes a specific Action from an account.
 func (client *InsightClient) DeleteAction(actionId string) error {
+	return client.DeleteActionContext(context.Background(), actionId)
+}
+
+// DeleteActionContext deletes a specific Action from an account using the provided context
+func (client *InsightClient) DeleteActionContext(ctx context.Context, actionId string) error {
 	endpoint, err := client.getActionEndpoint(actionId)
 	if err != nil {
 		return err
 	}
-	return client.delete(endpoint)
+	return client.deleteWithContext(ctx, endpoint)
 }
 
 func (client *InsightClient) getActionEndpoint(actionId string) (string, error) {
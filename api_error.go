@@ -0,0 +1,82 @@
+package insight_goclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned whenever the insight api answers with a status code other than the expected one
+type APIError struct {
+	Method             string
+	Path               string
+	ExpectedStatusCode int
+	StatusCode         int
+	// Body is the raw response body
+	Body []byte
+	// Message is the error message parsed from the response body, if any
+	Message string
+}
+
+// apiErrorResponse represents the error payloads returned by the insight api
+type apiErrorResponse struct {
+	Message string   `json:"message"`
+	Error   string   `json:"error"`
+	Errors  []string `json:"errors"`
+}
+
+func newAPIError(request *http.Request, response *http.Response, expectedResponseCode int, body []byte) *APIError {
+	return &APIError{
+		Method:             request.Method,
+		Path:               request.URL.Path,
+		ExpectedStatusCode: expectedResponseCode,
+		StatusCode:         response.StatusCode,
+		Body:               body,
+		Message:            parseAPIErrorMessage(body),
+	}
+}
+
+func parseAPIErrorMessage(body []byte) string {
+	var errorResponse apiErrorResponse
+	if err := json.Unmarshal(body, &errorResponse); err != nil {
+		return ""
+	}
+	if errorResponse.Message != "" {
+		return errorResponse.Message
+	}
+	if errorResponse.Error != "" {
+		return errorResponse.Error
+	}
+	return strings.Join(errorResponse.Errors, ", ")
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("Received a non expected response status code %d, expected code was %d. Response: %s", e.StatusCode, e.ExpectedStatusCode, string(e.Body))
+}
+
+// IsNotFound reports whether err is an APIError caused by a 404 Not Found response
+func IsNotFound(err error) bool {
+	return hasStatusCode(err, http.StatusNotFound)
+}
+
+// IsUnauthorized reports whether err is an APIError caused by a 401 Unauthorized or 403 Forbidden response
+func IsUnauthorized(err error) bool {
+	return hasStatusCode(err, http.StatusUnauthorized) || hasStatusCode(err, http.StatusForbidden)
+}
+
+// IsConflict reports whether err is an APIError caused by a 409 Conflict response
+func IsConflict(err error) bool {
+	return hasStatusCode(err, http.StatusConflict)
+}
+
+// IsRateLimited reports whether err is an APIError caused by a 429 Too Many Requests response
+func IsRateLimited(err error) bool {
+	return hasStatusCode(err, http.StatusTooManyRequests)
+}
+
+func hasStatusCode(err error, statusCode int) bool {
+	var apiError *APIError
+	return errors.As(err, &apiError) && apiError.StatusCode == statusCode
+}
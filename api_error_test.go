@@ -0,0 +1,41 @@
+package insight_goclient
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestAPIError_ReturnedOnUnexpectedStatusCode(t *testing.T) {
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/management/logs/log-uuid", nil, http.StatusNotFound, map[string]string{"message": "Log not found"})
+	client := getTestClient(requestMatcher)
+	_, err := client.GetLog("log-uuid")
+
+	apiError, ok := err.(*APIError)
+	assert.True(t, ok)
+	assert.Equal(t, http.MethodGet, apiError.Method)
+	assert.Equal(t, "/management/logs/log-uuid", apiError.Path)
+	assert.Equal(t, http.StatusOK, apiError.ExpectedStatusCode)
+	assert.Equal(t, http.StatusNotFound, apiError.StatusCode)
+	assert.Equal(t, `{"message":"Log not found"}`, string(apiError.Body))
+	assert.Equal(t, "Log not found", apiError.Message)
+	assert.True(t, IsNotFound(err))
+	assert.False(t, IsConflict(err))
+}
+
+func TestAPIError_Predicates(t *testing.T) {
+	assert.True(t, IsUnauthorized(&APIError{StatusCode: http.StatusUnauthorized}))
+	assert.True(t, IsUnauthorized(&APIError{StatusCode: http.StatusForbidden}))
+	assert.True(t, IsConflict(&APIError{StatusCode: http.StatusConflict}))
+	assert.True(t, IsRateLimited(&APIError{StatusCode: http.StatusTooManyRequests}))
+	assert.True(t, IsNotFound(fmt.Errorf("wrapped: %w", &APIError{StatusCode: http.StatusNotFound})))
+	assert.False(t, IsNotFound(fmt.Errorf("Received a non expected response status code 404")))
+	assert.False(t, IsNotFound(nil))
+}
+
+func TestAPIError_ParseMessage(t *testing.T) {
+	assert.Equal(t, "boom", parseAPIErrorMessage([]byte(`{"error":"boom"}`)))
+	assert.Equal(t, "a, b", parseAPIErrorMessage([]byte(`{"errors":["a","b"]}`)))
+	assert.Equal(t, "", parseAPIErrorMessage([]byte(`not json`)))
+}
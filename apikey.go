@@ -0,0 +1,191 @@
+package insight_goclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	APIKEYS_PATH = "/management/apikeys"
+	// API_KEY_TYPE_READ_ONLY keys can only be used to read data from the insight API
+	API_KEY_TYPE_READ_ONLY = "ReadOnly"
+	// API_KEY_TYPE_READ_WRITE keys can be used to both read and manage the resources of the account
+	API_KEY_TYPE_READ_WRITE = "ReadWrite"
+)
+
+// The ApiKeys resource allows you to interact with the API Keys of your account. The following operations are
+// supported:
+// - Get details of an existing Api Key
+// - Get details of a list of all Api Keys
+// - Create a new Api Key
+// - Enable or disable an existing Api Key
+// - Delete an Api Key
+
+// ApiKey represents the entity used to get an existing api key from the insight API. The Key itself is only returned
+// when the Api Key is created.
+type ApiKey struct {
+	Id      string `json:"id,omitempty"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Active  bool   `json:"active"`
+	Key     string `json:"key,omitempty"`
+	Created int64  `json:"created,omitempty"`
+}
+
+type ApiKeys struct {
+	ApiKeys []*ApiKey `json:"apikeys"`
+}
+
+type ApiKeyRequest struct {
+	ApiKey *ApiKey `json:"apikey"`
+}
+
+// apiKeyActivation only carries the active flag so that enabling or disabling a key leaves its other fields untouched
+type apiKeyActivation struct {
+	Active bool `json:"active"`
+}
+
+type apiKeyActivationRequest struct {
+	ApiKey *apiKeyActivation `json:"apikey"`
+}
+
+// IsReadOnly reports whether the Api Key can only be used to read data
+func (apiKey *ApiKey) IsReadOnly() bool {
+	return apiKey.Type == API_KEY_TYPE_READ_ONLY
+}
+
+// GetApiKeys gets details of a list of all Api Keys
+func (client *InsightClient) GetApiKeys() ([]*ApiKey, error) {
+	return client.GetApiKeysContext(context.Background())
+}
+
+// GetApiKeysContext gets details of a list of all Api Keys using the provided context
+func (client *InsightClient) GetApiKeysContext(ctx context.Context) ([]*ApiKey, error) {
+	var result []*ApiKey
+	err := client.ForEachApiKeyContext(ctx, func(apiKey *ApiKey) error {
+		result = append(result, apiKey)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ForEachApiKey calls fn for every Api Key of the account, fetching them page by page
+func (client *InsightClient) ForEachApiKey(fn func(apiKey *ApiKey) error) error {
+	return client.ForEachApiKeyContext(context.Background(), fn)
+}
+
+// ForEachApiKeyContext calls fn for every Api Key of the account, fetching them page by page using the provided
+// context. Iteration stops at the first error returned by fn; return ErrStopIteration to stop without failing
+func (client *InsightClient) ForEachApiKeyContext(ctx context.Context, fn func(apiKey *ApiKey) error) error {
+	var apiKeys ApiKeys
+	return client.getPages(ctx, APIKEYS_PATH, &apiKeys, func() error {
+		for _, apiKey := range apiKeys.ApiKeys {
+			if err := fn(apiKey); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetApiKey gets a specific Api Key from an account
+func (client *InsightClient) GetApiKey(apiKeyId string) (*ApiKey, error) {
+	return client.GetApiKeyContext(context.Background(), apiKeyId)
+}
+
+// GetApiKeyContext gets a specific Api Key from an account using the provided context
+func (client *InsightClient) GetApiKeyContext(ctx context.Context, apiKeyId string) (*ApiKey, error) {
+	var apiKeyRequest ApiKeyRequest
+	endpoint, err := client.getApiKeyEndpoint(apiKeyId)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.getWithContext(ctx, endpoint, &apiKeyRequest); err != nil {
+		return nil, err
+	}
+	return apiKeyRequest.ApiKey, nil
+}
+
+// PostApiKey creates a new Api Key. On success the generated Key is set on apiKey
+func (client *InsightClient) PostApiKey(apiKey *ApiKey) error {
+	return client.PostApiKeyContext(context.Background(), apiKey)
+}
+
+// PostApiKeyContext creates a new Api Key using the provided context. On success the generated Key is set on apiKey
+func (client *InsightClient) PostApiKeyContext(ctx context.Context, apiKey *ApiKey) error {
+	if apiKey.Type != API_KEY_TYPE_READ_ONLY && apiKey.Type != API_KEY_TYPE_READ_WRITE {
+		return fmt.Errorf("Unknown api key type %s, expected one of: %s, %s", apiKey.Type, API_KEY_TYPE_READ_ONLY, API_KEY_TYPE_READ_WRITE)
+	}
+	apiKeyRequest := ApiKeyRequest{apiKey}
+	resp, err := client.postWithContext(ctx, APIKEYS_PATH, apiKeyRequest)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(resp, &apiKeyRequest)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// EnableApiKey enables a specific Api Key from an account
+func (client *InsightClient) EnableApiKey(apiKeyId string) (*ApiKey, error) {
+	return client.EnableApiKeyContext(context.Background(), apiKeyId)
+}
+
+// EnableApiKeyContext enables a specific Api Key from an account using the provided context
+func (client *InsightClient) EnableApiKeyContext(ctx context.Context, apiKeyId string) (*ApiKey, error) {
+	return client.setApiKeyActive(ctx, apiKeyId, true)
+}
+
+// DisableApiKey disables a specific Api Key from an account; requests made with a disabled key are rejected
+func (client *InsightClient) DisableApiKey(apiKeyId string) (*ApiKey, error) {
+	return client.DisableApiKeyContext(context.Background(), apiKeyId)
+}
+
+// DisableApiKeyContext disables a specific Api Key from an account using the provided context
+func (client *InsightClient) DisableApiKeyContext(ctx context.Context, apiKeyId string) (*ApiKey, error) {
+	return client.setApiKeyActive(ctx, apiKeyId, false)
+}
+
+func (client *InsightClient) setApiKeyActive(ctx context.Context, apiKeyId string, active bool) (*ApiKey, error) {
+	endpoint, err := client.getApiKeyEndpoint(apiKeyId)
+	if err != nil {
+		return nil, err
+	}
+	var apiKeyRequest ApiKeyRequest
+	resp, err := client.putWithContext(ctx, endpoint, apiKeyActivationRequest{&apiKeyActivation{active}})
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(resp, &apiKeyRequest); err != nil {
+		return nil, err
+	}
+	return apiKeyRequest.ApiKey, nil
+}
+
+// DeleteApiKey deletes a specific Api Key from an account.
+func (client *InsightClient) DeleteApiKey(apiKeyId string) error {
+	return client.DeleteApiKeyContext(context.Background(), apiKeyId)
+}
+
+// DeleteApiKeyContext deletes a specific Api Key from an account using the provided context
+func (client *InsightClient) DeleteApiKeyContext(ctx context.Context, apiKeyId string) error {
+	endpoint, err := client.getApiKeyEndpoint(apiKeyId)
+	if err != nil {
+		return err
+	}
+	return client.deleteWithContext(ctx, endpoint)
+}
+
+func (client *InsightClient) getApiKeyEndpoint(apiKeyId string) (string, error) {
+	if apiKeyId == "" {
+		return "", fmt.Errorf("apiKeyId input parameter is mandatory")
+	} else {
+		return fmt.Sprintf("%s/%s", APIKEYS_PATH, apiKeyId), nil
+	}
+}
@@ -0,0 +1,107 @@
+package insight_goclient
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func getTestApiKey() *ApiKey {
+	return &ApiKey{
+		Id:      "apikey-uuid",
+		Name:    "ci",
+		Type:    API_KEY_TYPE_READ_ONLY,
+		Active:  true,
+		Created: 1557187200000,
+	}
+}
+
+func TestApiKeys_GetApiKeys(t *testing.T) {
+	expectedApiKeys := []*ApiKey{getTestApiKey()}
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/management/apikeys", nil, http.StatusOK, ApiKeys{expectedApiKeys})
+	client := getTestClient(requestMatcher)
+	returnedApiKeys, err := client.GetApiKeys()
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedApiKeys, returnedApiKeys)
+	assert.True(t, returnedApiKeys[0].IsReadOnly())
+}
+
+func TestApiKeys_GetApiKey(t *testing.T) {
+	expectedApiKey := getTestApiKey()
+	url := fmt.Sprintf("/management/apikeys/%s", expectedApiKey.Id)
+	requestMatcher := NewRequestMatcher(http.MethodGet, url, nil, http.StatusOK, ApiKeyRequest{expectedApiKey})
+	client := getTestClient(requestMatcher)
+	returnedApiKey, err := client.GetApiKey(expectedApiKey.Id)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedApiKey, returnedApiKey)
+}
+
+func TestApiKeys_GetApiKeyErrorsIfApiKeyIdIsEmpty(t *testing.T) {
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/management/apikeys/", nil, http.StatusOK, ApiKeyRequest{&ApiKey{}})
+	client := getTestClient(requestMatcher)
+	_, err := client.GetApiKey("")
+	assert.NotNil(t, err)
+	assert.Error(t, err, "apiKeyId input parameter is mandatory")
+}
+
+func TestApiKeys_PostApiKey(t *testing.T) {
+	p := &ApiKey{
+		Name:   "rotation",
+		Type:   API_KEY_TYPE_READ_WRITE,
+		Active: true,
+	}
+
+	expectedApiKey := &ApiKey{
+		Id:     "apikey-uuid",
+		Name:   p.Name,
+		Type:   p.Type,
+		Active: true,
+		Key:    "generated-key",
+	}
+
+	requestMatcher := NewRequestMatcher(http.MethodPost, "/management/apikeys", ApiKeyRequest{p}, http.StatusCreated, ApiKeyRequest{expectedApiKey})
+	client := getTestClient(requestMatcher)
+	err := client.PostApiKey(p)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedApiKey, p)
+}
+
+func TestApiKeys_PostApiKeyErrorsIfTypeIsUnknown(t *testing.T) {
+	requestMatcher := NewRequestMatcher(http.MethodPost, "/management/apikeys", nil, http.StatusCreated, nil)
+	client := getTestClient(requestMatcher)
+	err := client.PostApiKey(&ApiKey{Name: "rotation", Type: "Admin"})
+	assert.NotNil(t, err)
+}
+
+func TestApiKeys_DisableApiKey(t *testing.T) {
+	expectedApiKey := getTestApiKey()
+	expectedApiKey.Active = false
+	url := fmt.Sprintf("/management/apikeys/%s", expectedApiKey.Id)
+	payload := apiKeyActivationRequest{&apiKeyActivation{false}}
+	requestMatcher := NewRequestMatcher(http.MethodPut, url, payload, http.StatusOK, ApiKeyRequest{expectedApiKey})
+	client := getTestClient(requestMatcher)
+	returnedApiKey, err := client.DisableApiKey(expectedApiKey.Id)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedApiKey, returnedApiKey)
+}
+
+func TestApiKeys_EnableApiKey(t *testing.T) {
+	expectedApiKey := getTestApiKey()
+	url := fmt.Sprintf("/management/apikeys/%s", expectedApiKey.Id)
+	payload := apiKeyActivationRequest{&apiKeyActivation{true}}
+	requestMatcher := NewRequestMatcher(http.MethodPut, url, payload, http.StatusOK, ApiKeyRequest{expectedApiKey})
+	client := getTestClient(requestMatcher)
+	returnedApiKey, err := client.EnableApiKey(expectedApiKey.Id)
+	assert.Nil(t, err)
+	assert.True(t, returnedApiKey.Active)
+}
+
+func TestApiKeys_DeleteApiKey(t *testing.T) {
+	apiKeyId := "apikey-uuid"
+	url := fmt.Sprintf("/management/apikeys/%s", apiKeyId)
+	requestMatcher := NewRequestMatcher(http.MethodDelete, url, nil, http.StatusNoContent, nil)
+	client := getTestClient(requestMatcher)
+	err := client.DeleteApiKey(apiKeyId)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,198 @@
+package insight_goclient
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ClientOption configures an InsightClient created via NewInsightClientWithOptions
+type ClientOption func(options *clientOptions) error
+
+// clientOptions holds the settings collected from the ClientOption list before the client is built
+type clientOptions struct {
+	httpClient *http.Client
+	baseUrl    string
+	userAgent  string
+	timeout    time.Duration
+	tlsConfig  *tls.Config
+	proxy      func(*http.Request) (*url.URL, error)
+
+	retryPolicy    *RetryPolicy
+	retryPolicySet bool
+	rateLimiter    *RateLimiter
+	middlewares    []Middleware
+	logger         Logger
+	logBodies      bool
+	credentials    CredentialsProvider
+	unlistedRegion bool
+}
+
+// WithHttpClient makes the insight client use the given http client instead of a default one
+func WithHttpClient(httpClient *http.Client) ClientOption {
+	return func(options *clientOptions) error {
+		if httpClient == nil {
+			return fmt.Errorf("httpClient input parameter is mandatory")
+		}
+		options.httpClient = httpClient
+		return nil
+	}
+}
+
+// WithBaseUrl overrides the insight url derived from the region, e.g: to talk to a proxy or a local stand-in
+func WithBaseUrl(baseUrl string) ClientOption {
+	return func(options *clientOptions) error {
+		parsedUrl, err := url.Parse(baseUrl)
+		if err != nil {
+			return fmt.Errorf("Invalid base url %s: %s", baseUrl, err)
+		}
+		if (parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https") || parsedUrl.Host == "" {
+			return fmt.Errorf("Invalid base url %s: an absolute http or https url is expected", baseUrl)
+		}
+		options.baseUrl = strings.TrimSuffix(baseUrl, "/")
+		return nil
+	}
+}
+
+// WithUnlistedRegion accepts a region missing from Regions, e.g: one opened after this version of the client was
+// released, its endpoint being derived from the region like for the known ones
+func WithUnlistedRegion() ClientOption {
+	return func(options *clientOptions) error {
+		options.unlistedRegion = true
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent along with every request
+func WithUserAgent(userAgent string) ClientOption {
+	return func(options *clientOptions) error {
+		if userAgent == "" {
+			return fmt.Errorf("userAgent input parameter is mandatory")
+		}
+		options.userAgent = userAgent
+		return nil
+	}
+}
+
+// WithTimeout sets the default timeout applied to every request sent by the client
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(options *clientOptions) error {
+		if timeout <= 0 {
+			return fmt.Errorf("timeout must be greater than zero, got %s", timeout)
+		}
+		options.timeout = timeout
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used when connecting to the insight api
+func WithTLSConfig(tlsConfig *tls.Config) ClientOption {
+	return func(options *clientOptions) error {
+		if tlsConfig == nil {
+			return fmt.Errorf("tlsConfig input parameter is mandatory")
+		}
+		options.tlsConfig = tlsConfig
+		return nil
+	}
+}
+
+// WithProxy routes every request through the proxy located at proxyUrl
+func WithProxy(proxyUrl string) ClientOption {
+	return func(options *clientOptions) error {
+		parsedUrl, err := url.Parse(proxyUrl)
+		if err != nil {
+			return fmt.Errorf("Invalid proxy url %s: %s", proxyUrl, err)
+		}
+		if parsedUrl.Scheme == "" || parsedUrl.Host == "" {
+			return fmt.Errorf("Invalid proxy url %s: an absolute url is expected", proxyUrl)
+		}
+		options.proxy = http.ProxyURL(parsedUrl)
+		return nil
+	}
+}
+
+// NewInsightClientWithOptions creates a insight client like NewInsightClient, customised by the given options.
+// The region may be left empty when WithBaseUrl is provided, e.g: to use a proxy or a local fake of the api.
+func NewInsightClientWithOptions(apiKey, region string, options ...ClientOption) (*InsightClient, error) {
+	opts := &clientOptions{}
+	for _, option := range options {
+		if err := option(opts); err != nil {
+			return nil, err
+		}
+	}
+	if apiKey == "" && opts.credentials == nil {
+		return nil, fmt.Errorf("ApiKey is mandatory to initialize Insight client")
+	}
+	if region == "" && opts.baseUrl == "" {
+		return nil, fmt.Errorf("Region is mandatory to initialize Insight client")
+	}
+	if region != "" {
+		validate := ValidateRegion
+		if opts.unlistedRegion {
+			validate = validateUnlistedRegion
+		}
+		if err := validate(region); err != nil {
+			return nil, err
+		}
+	}
+	httpClient, err := opts.buildHttpClient()
+	if err != nil {
+		return nil, err
+	}
+	insightUrl := opts.baseUrl
+	if insightUrl == "" {
+		insightUrl = fmt.Sprintf(INSIGHT_API, region)
+	}
+	retryPolicy := DefaultRetryPolicy()
+	if opts.retryPolicySet {
+		retryPolicy = opts.retryPolicy
+	}
+	return &InsightClient{
+		InsightUrl:  insightUrl,
+		ApiKey:      apiKey,
+		HttpClient:  httpClient,
+		UserAgent:   opts.userAgent,
+		RetryPolicy: retryPolicy,
+		RateLimiter: opts.rateLimiter,
+		Middlewares: opts.middlewares,
+		Logger:      opts.logger,
+		LogBodies:   opts.logBodies,
+		Credentials: opts.credentials,
+	}, nil
+}
+
+// buildHttpClient returns the http client to be used by the insight client. A user provided client is copied
+// rather than mutated so that it can still be shared with other consumers.
+func (opts *clientOptions) buildHttpClient() (*http.Client, error) {
+	httpClient := &http.Client{}
+	if opts.httpClient != nil {
+		clientCopy := *opts.httpClient
+		httpClient = &clientCopy
+	}
+	if opts.timeout > 0 {
+		httpClient.Timeout = opts.timeout
+	}
+	if opts.tlsConfig == nil && opts.proxy == nil {
+		return httpClient, nil
+	}
+	var transport *http.Transport
+	switch t := httpClient.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return nil, fmt.Errorf("TLS config and proxy options require the http client transport to be an *http.Transport, got %T", t)
+	}
+	if opts.tlsConfig != nil {
+		transport.TLSClientConfig = opts.tlsConfig
+	}
+	if opts.proxy != nil {
+		transport.Proxy = opts.proxy
+	}
+	httpClient.Transport = transport
+	return httpClient, nil
+}
@@ -0,0 +1,94 @@
+package insight_goclient
+
+import (
+	"crypto/tls"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientOptions_NewInsightClientWithOptionsDefaults(t *testing.T) {
+	c, err := NewInsightClientWithOptions("apiKey", "eu")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://eu.rest.logs.insight.rapid7.com", c.InsightUrl)
+	assert.NotNil(t, c.HttpClient)
+}
+
+func TestClientOptions_WithBaseUrlAndUserAgent(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "my-agent/1.0", r.Header.Get("User-Agent"))
+		assert.Equal(t, "apiKey", r.Header.Get("x-api-key"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer httpServer.Close()
+
+	c, err := NewInsightClientWithOptions("apiKey", "", WithBaseUrl(httpServer.URL+"/"), WithUserAgent("my-agent/1.0"))
+	assert.Nil(t, err)
+	assert.Equal(t, httpServer.URL, c.InsightUrl)
+	assert.Nil(t, c.DeleteLog("log-uuid"))
+}
+
+func TestClientOptions_WithProxy(t *testing.T) {
+	requestMatcher := NewRequestMatcher(http.MethodDelete, "/management/logs/log-uuid", nil, http.StatusNoContent, nil)
+	testClientServer := TestClientServer{RequestMatcher: requestMatcher}
+	_, httpServer := testClientServer.TestClientServer()
+	defer httpServer.Close()
+
+	c, err := NewInsightClientWithOptions("apiKey", "", WithBaseUrl("http://insight.invalid"), WithProxy(httpServer.URL))
+	assert.Nil(t, err)
+	assert.Nil(t, c.DeleteLog("log-uuid"))
+}
+
+func TestClientOptions_WithTLSConfig(t *testing.T) {
+	httpServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer httpServer.Close()
+	tlsConfig := httpServer.Client().Transport.(*http.Transport).TLSClientConfig
+
+	c, err := NewInsightClientWithOptions("apiKey", "", WithBaseUrl(httpServer.URL), WithTLSConfig(tlsConfig))
+	assert.Nil(t, err)
+	assert.Nil(t, c.DeleteLog("log-uuid"))
+}
+
+func TestClientOptions_WithHttpClientIsNotMutated(t *testing.T) {
+	httpClient := &http.Client{}
+	c, err := NewInsightClientWithOptions("apiKey", "eu", WithHttpClient(httpClient), WithTimeout(5*time.Second), WithTLSConfig(&tls.Config{}))
+	assert.Nil(t, err)
+	assert.Equal(t, 5*time.Second, c.HttpClient.Timeout)
+	assert.NotNil(t, c.HttpClient.Transport)
+	assert.Equal(t, time.Duration(0), httpClient.Timeout)
+	assert.Nil(t, httpClient.Transport)
+}
+
+func TestClientOptions_InvalidOptions(t *testing.T) {
+	_, err := NewInsightClientWithOptions("apiKey", "eu", WithHttpClient(nil))
+	assert.NotNil(t, err)
+	_, err = NewInsightClientWithOptions("apiKey", "", WithBaseUrl("not-a-url"))
+	assert.NotNil(t, err)
+	_, err = NewInsightClientWithOptions("apiKey", "eu", WithUserAgent(""))
+	assert.NotNil(t, err)
+	_, err = NewInsightClientWithOptions("apiKey", "eu", WithTimeout(-time.Second))
+	assert.NotNil(t, err)
+	_, err = NewInsightClientWithOptions("apiKey", "eu", WithTLSConfig(nil))
+	assert.NotNil(t, err)
+	_, err = NewInsightClientWithOptions("apiKey", "eu", WithProxy("proxy"))
+	assert.NotNil(t, err)
+	_, err = NewInsightClientWithOptions("apiKey", "")
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "Region is mandatory to initialize Insight client")
+}
+
+type noopRoundTripper struct{}
+
+func (noopRoundTripper) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func TestClientOptions_TLSConfigRequiresHttpTransport(t *testing.T) {
+	httpClient := &http.Client{Transport: noopRoundTripper{}}
+	_, err := NewInsightClientWithOptions("apiKey", "eu", WithHttpClient(httpClient), WithTLSConfig(&tls.Config{}))
+	assert.NotNil(t, err)
+}
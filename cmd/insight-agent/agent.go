@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	insight "github.com/Tweddle-SE-Team/insight_goclient"
+)
+
+// LogLister is the part of the insight client used by the agent
+type LogLister interface {
+	GetLogsContext(ctx context.Context) ([]*insight.Log, error)
+}
+
+// Sender is the part of the insight Shipper used by the agent
+type Sender interface {
+	SendWithToken(token, line string) error
+	Flush(ctx context.Context) error
+}
+
+// Agent follows the files of the logs whose AgentFileName matches files of this host and have AgentFollow set,
+// shipping their new lines with the token of their log
+type Agent struct {
+	Logs            LogLister
+	Sender          Sender
+	State           *OffsetStore
+	PollInterval    time.Duration
+	RefreshInterval time.Duration
+
+	tailers map[string]*Tailer
+}
+
+// FollowedFiles maps the files of this host to the token of the log following them. AgentFileName may be a glob
+// pattern; a file matched by several logs is shipped to the first one only.
+func FollowedFiles(logs []*insight.Log) map[string]string {
+	files := map[string]string{}
+	for _, insightLog := range logs {
+		if insightLog.UserData == nil || !bool(insightLog.UserData.AgentFollow) || insightLog.UserData.AgentFileName == "" {
+			continue
+		}
+		if len(insightLog.Tokens) == 0 {
+			log.Printf("Log %s follows %s but has no token, skipping it", insightLog.Name, insightLog.UserData.AgentFileName)
+			continue
+		}
+		paths, err := filepath.Glob(insightLog.UserData.AgentFileName)
+		if err != nil {
+			log.Printf("Log %s follows an invalid file pattern %s: %s", insightLog.Name, insightLog.UserData.AgentFileName, err)
+			continue
+		}
+		if len(paths) == 0 && !strings.ContainsAny(insightLog.UserData.AgentFileName, "*?[") {
+			// Follow files which do not exist yet, they will be shipped once created
+			paths = []string{insightLog.UserData.AgentFileName}
+		}
+		for _, path := range paths {
+			if _, ok := files[path]; ok {
+				log.Printf("File %s is followed by several logs, shipping it to the first one only", path)
+				continue
+			}
+			files[path] = insightLog.Tokens[0]
+		}
+	}
+	return files
+}
+
+// Run follows the files until ctx is done. The followed files are refreshed from the logs of the account every
+// RefreshInterval, their new lines are shipped every PollInterval.
+func (agent *Agent) Run(ctx context.Context) error {
+	defer agent.closeTailers()
+	if err := agent.refresh(ctx); err != nil {
+		return err
+	}
+	poll := time.NewTicker(agent.PollInterval)
+	defer poll.Stop()
+	refresh := time.NewTicker(agent.RefreshInterval)
+	defer refresh.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return agent.State.Save()
+		case <-refresh.C:
+			if err := agent.refresh(ctx); err != nil {
+				log.Printf("Unable to refresh the followed files, keeping the current ones: %s", err)
+			}
+		case <-poll.C:
+			agent.Poll(ctx)
+		}
+	}
+}
+
+// Poll ships the new lines of every followed file and saves their offsets. The lines are read in batches of
+// MAX_POLL_BYTES, each of them being flushed before the next one is read so that the shipper buffer never overflows,
+// and the offset of a file is only saved once its lines have been flushed.
+func (agent *Agent) Poll(ctx context.Context) {
+	for _, path := range agent.paths() {
+		if !agent.poll(ctx, path) {
+			break
+		}
+	}
+	if err := agent.State.Save(); err != nil {
+		log.Printf("Unable to save the offsets of the followed files: %s", err)
+	}
+}
+
+// poll ships the new lines of a file, returning false when they could not be flushed
+func (agent *Agent) poll(ctx context.Context, path string) bool {
+	tailer := agent.tailers[path]
+	for {
+		err := tailer.Poll(func(line string) error {
+			return agent.Sender.SendWithToken(tailer.Token, line)
+		})
+		if err != nil {
+			log.Printf("Unable to follow %s: %s", path, err)
+		}
+		if err := agent.Sender.Flush(ctx); err != nil {
+			log.Printf("Unable to flush the lines of %s, keeping its saved offset: %s", path, err)
+			return false
+		}
+		if tailer.opened {
+			agent.State.Set(path, tailer.Offset())
+		}
+		if err != nil || !tailer.Behind() {
+			return true
+		}
+	}
+}
+
+// refresh starts following the files of new logs and stops following the ones no longer configured
+func (agent *Agent) refresh(ctx context.Context) error {
+	logs, err := agent.Logs.GetLogsContext(ctx)
+	if err != nil {
+		return err
+	}
+	files := FollowedFiles(logs)
+	if agent.tailers == nil {
+		agent.tailers = map[string]*Tailer{}
+	}
+	for path, tailer := range agent.tailers {
+		if token, ok := files[path]; !ok || token != tailer.Token {
+			log.Printf("Stopped following %s", path)
+			tailer.Close()
+			delete(agent.tailers, path)
+			agent.State.Delete(path)
+		}
+	}
+	for path, token := range files {
+		if _, ok := agent.tailers[path]; ok {
+			continue
+		}
+		var saved *FileOffset
+		if offset, ok := agent.State.Get(path); ok {
+			saved = &offset
+		}
+		log.Printf("Following %s", path)
+		agent.tailers[path] = NewTailer(path, token, saved)
+	}
+	return nil
+}
+
+func (agent *Agent) paths() []string {
+	paths := make([]string, 0, len(agent.tailers))
+	for path := range agent.tailers {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (agent *Agent) closeTailers() {
+	for _, tailer := range agent.tailers {
+		tailer.Close()
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	insight "github.com/Tweddle-SE-Team/insight_goclient"
+)
+
+type testLogLister struct {
+	logs []*insight.Log
+}
+
+func (lister *testLogLister) GetLogsContext(ctx context.Context) ([]*insight.Log, error) {
+	return lister.logs, nil
+}
+
+type testSender struct {
+	lines    []string
+	pending  int
+	flushes  []int
+	flushErr error
+}
+
+func (sender *testSender) SendWithToken(token, line string) error {
+	sender.lines = append(sender.lines, token+" "+line)
+	sender.pending++
+	return nil
+}
+
+func (sender *testSender) Flush(ctx context.Context) error {
+	if sender.flushErr != nil {
+		return sender.flushErr
+	}
+	sender.flushes = append(sender.flushes, sender.pending)
+	sender.pending = 0
+	return nil
+}
+
+func newAgentTestLog(name, fileName string, follow bool, tokens ...string) *insight.Log {
+	return &insight.Log{
+		Name:     name,
+		Tokens:   tokens,
+		UserData: &insight.LogUserData{AgentFileName: fileName, AgentFollow: insight.StringBool(follow)},
+	}
+}
+
+func TestAgent_FollowedFiles(t *testing.T) {
+	dir := newTailerTestDir(t)
+	defer os.RemoveAll(dir)
+	appendTailerTestFile(t, filepath.Join(dir, "a.log"), "")
+	appendTailerTestFile(t, filepath.Join(dir, "b.log"), "")
+
+	files := FollowedFiles([]*insight.Log{
+		newAgentTestLog("all", filepath.Join(dir, "*.log"), true, "all-token"),
+		newAgentTestLog("b", filepath.Join(dir, "b.log"), true, "b-token"),
+		newAgentTestLog("not followed", filepath.Join(dir, "c.log"), false, "c-token"),
+		newAgentTestLog("no token", filepath.Join(dir, "d.log"), true),
+		newAgentTestLog("missing", filepath.Join(dir, "later.log"), true, "later-token"),
+		{Name: "no user data"},
+	})
+	assert.Equal(t, map[string]string{
+		filepath.Join(dir, "a.log"):     "all-token",
+		filepath.Join(dir, "b.log"):     "all-token",
+		filepath.Join(dir, "later.log"): "later-token",
+	}, files)
+}
+
+func TestAgent_PollShipsLinesAndSavesOffsets(t *testing.T) {
+	dir := newTailerTestDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	statePath := filepath.Join(dir, "state", "offsets.json")
+	appendTailerTestFile(t, path, "history\n")
+
+	state, err := LoadOffsetStore(statePath)
+	assert.Nil(t, err)
+	sender := &testSender{}
+	lister := &testLogLister{logs: []*insight.Log{newAgentTestLog("app", path, true, "app-token")}}
+	agent := &Agent{Logs: lister, Sender: sender, State: state}
+	assert.Nil(t, agent.refresh(context.Background()))
+	agent.Poll(context.Background())
+	appendTailerTestFile(t, path, "new line\n")
+	agent.Poll(context.Background())
+	agent.closeTailers()
+	assert.Equal(t, []string{"app-token new line"}, sender.lines)
+
+	state, err = LoadOffsetStore(statePath)
+	assert.Nil(t, err)
+	offset, ok := state.Get(path)
+	assert.True(t, ok)
+	assert.Equal(t, int64(17), offset.Offset)
+
+	lister.logs = nil
+	agent.State = state
+	assert.Nil(t, agent.refresh(context.Background()))
+	assert.Empty(t, agent.tailers)
+	_, ok = state.Get(path)
+	assert.False(t, ok)
+}
+
+func TestAgent_PollFlushesBatchesBeforeSavingOffsets(t *testing.T) {
+	dir := newTailerTestDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	appendTailerTestFile(t, path, "")
+
+	state, err := LoadOffsetStore(filepath.Join(dir, "offsets.json"))
+	assert.Nil(t, err)
+	sender := &testSender{}
+	lister := &testLogLister{logs: []*insight.Log{newAgentTestLog("app", path, true, "app-token")}}
+	agent := &Agent{Logs: lister, Sender: sender, State: state}
+	assert.Nil(t, agent.refresh(context.Background()))
+	defer agent.closeTailers()
+	agent.Poll(context.Background())
+
+	line := strings.Repeat("x", 1023) + "\n"
+	batch := MAX_POLL_BYTES / len(line)
+	appendTailerTestFile(t, path, strings.Repeat(line, batch+10))
+	sender.flushes = nil
+	agent.Poll(context.Background())
+	assert.Equal(t, []int{batch, 10}, sender.flushes)
+	offset, _ := state.Get(path)
+	assert.Equal(t, int64((batch+10)*len(line)), offset.Offset)
+
+	appendTailerTestFile(t, path, "unflushed\n")
+	sender.flushErr = fmt.Errorf("unreachable")
+	agent.Poll(context.Background())
+	offset, _ = state.Get(path)
+	assert.Equal(t, int64((batch+10)*len(line)), offset.Offset)
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package main
+
+import (
+	"os"
+)
+
+// getFileIdentity cannot identify files on this platform, rotation is then only detected through truncation
+func getFileIdentity(info os.FileInfo) fileIdentity {
+	return fileIdentity{}
+}
@@ -0,0 +1,18 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// getFileIdentity identifies a file by its device and inode, which survive renames
+func getFileIdentity(info os.FileInfo) fileIdentity {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileIdentity{}
+	}
+	return fileIdentity{Device: uint64(stat.Dev), Inode: uint64(stat.Ino)}
+}
@@ -0,0 +1,68 @@
+// Command insight-agent follows the files of this host configured on the insight logs of the account, i.e. the logs
+// whose le_agent_filename user data matches a file of this host and le_agent_follow is true, and ships their new lines
+// with the token of their log. It is a replacement for the legacy Logentries agent.
+//
+// The api key is read from the INSIGHT_API_KEY environment variable.
+//
+//	insight-agent -region eu -state /var/lib/insight-agent/offsets.json
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	insight "github.com/Tweddle-SE-Team/insight_goclient"
+)
+
+func main() {
+	region := flag.String("region", os.Getenv(insight.REGION_ENV_VARIABLE), "insight region of the account")
+	statePath := flag.String("state", "/var/lib/insight-agent/offsets.json", "file persisting the offsets of the followed files")
+	pollInterval := flag.Duration("poll", time.Second, "interval between two reads of the followed files")
+	refreshInterval := flag.Duration("refresh", 5*time.Minute, "interval between two refreshes of the followed files from the account logs")
+	flag.Parse()
+
+	if *pollInterval <= 0 || *refreshInterval <= 0 {
+		log.Fatal("-poll and -refresh must be greater than zero")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	client, err := insight.NewInsightClientWithOptions("", *region, insight.WithCredentials(&insight.EnvCredentials{}))
+	if err != nil {
+		log.Fatal(err)
+	}
+	state, err := LoadOffsetStore(*statePath)
+	if err != nil {
+		log.Fatalf("Unable to load the offsets from %s: %s", *statePath, err)
+	}
+	shipper, err := insight.NewShipper(*region, "")
+	if err != nil {
+		log.Fatal(err)
+	}
+	agent := &Agent{
+		Logs:            client,
+		Sender:          shipper,
+		State:           state,
+		PollInterval:    *pollInterval,
+		RefreshInterval: *refreshInterval,
+	}
+	runErr := agent.Run(ctx)
+	if err := shipper.Close(); err != nil {
+		log.Print(err)
+	}
+	if runErr != nil {
+		log.Fatal(runErr)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// fileIdentity identifies a file regardless of its name, see getFileIdentity
+type fileIdentity struct {
+	Device uint64 `json:"device"`
+	Inode  uint64 `json:"inode"`
+}
+
+// FileOffset is the position up to which a followed file has been shipped
+type FileOffset struct {
+	fileIdentity
+	Offset int64 `json:"offset"`
+}
+
+// OffsetStore persists the offsets of the followed files so that the agent resumes where it stopped
+type OffsetStore struct {
+	path    string
+	mutex   sync.Mutex
+	offsets map[string]FileOffset
+	dirty   bool
+}
+
+// LoadOffsetStore loads the offsets saved in path, starting from scratch when the file does not exist yet
+func LoadOffsetStore(path string) (*OffsetStore, error) {
+	store := &OffsetStore{path: path, offsets: map[string]FileOffset{}}
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &store.offsets); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Get returns the saved offset of a file
+func (store *OffsetStore) Get(path string) (FileOffset, bool) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	offset, ok := store.offsets[path]
+	return offset, ok
+}
+
+// Set records the offset of a file, which is persisted by the next Save
+func (store *OffsetStore) Set(path string, offset FileOffset) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.offsets[path] != offset {
+		store.offsets[path] = offset
+		store.dirty = true
+	}
+}
+
+// Delete forgets the offset of a file which is not followed anymore
+func (store *OffsetStore) Delete(path string) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if _, ok := store.offsets[path]; ok {
+		delete(store.offsets, path)
+		store.dirty = true
+	}
+}
+
+// Save atomically writes the offsets if they changed since the last Save
+func (store *OffsetStore) Save() error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if !store.dirty {
+		return nil
+	}
+	content, err := json.MarshalIndent(store.offsets, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(store.path), 0700); err != nil {
+		return err
+	}
+	temporaryPath := store.path + ".tmp"
+	file, err := os.OpenFile(temporaryPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(temporaryPath, store.path); err != nil {
+		return err
+	}
+	store.dirty = false
+	return nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+const (
+	READ_BUFFER_BYTES = 32 * 1024
+	// MAX_LINE_BYTES bounds the size of a line; longer lines are shipped in several parts
+	MAX_LINE_BYTES = 64 * 1024
+	// MAX_POLL_BYTES bounds the bytes read from a file per Poll so that a backlog is shipped in batches which fit in
+	// the shipper buffer
+	MAX_POLL_BYTES = 1024 * 1024
+)
+
+// Tailer follows a file, shipping every line appended to it. Rotations, i.e. the file being renamed and recreated,
+// and truncations are detected on every Poll.
+type Tailer struct {
+	Path  string
+	Token string
+
+	file     *os.File
+	identity fileIdentity
+	offset   int64
+	partial  []byte
+	// saved is the offset to resume from when the file is opened for the first time, nil to start at its end
+	saved  *FileOffset
+	opened bool
+	// behind is set when the last Poll stopped at MAX_POLL_BYTES before the end of the file
+	behind bool
+}
+
+// NewTailer creates a Tailer resuming from the saved offset, or starting at the end of the file when there is none
+// so that its history is not shipped again
+func NewTailer(path, token string, saved *FileOffset) *Tailer {
+	return &Tailer{Path: path, Token: token, saved: saved}
+}
+
+// Offset returns the position up to which the file has been shipped
+func (tailer *Tailer) Offset() FileOffset {
+	return FileOffset{fileIdentity: tailer.identity, Offset: tailer.offset}
+}
+
+// Behind reports whether the last Poll left lines to be read, see MAX_POLL_BYTES
+func (tailer *Tailer) Behind() bool {
+	return tailer.behind
+}
+
+// Poll ships the lines appended to the file since the last Poll, up to MAX_POLL_BYTES. A trailing line without
+// newline is held back until it is completed, unless the file has been rotated.
+func (tailer *Tailer) Poll(send func(line string) error) error {
+	tailer.behind = false
+	info, err := os.Stat(tailer.Path)
+	if err != nil {
+		if os.IsNotExist(err) && tailer.file != nil {
+			// Rotated away and not recreated yet, ship what was written before the rotation
+			return tailer.read(send)
+		}
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	identity := getFileIdentity(info)
+	if tailer.file != nil && identity != tailer.identity {
+		if err := tailer.read(send); err != nil || tailer.behind {
+			// Finish shipping the rotated file before switching to the new one
+			return err
+		}
+		if err := tailer.flushPartial(send); err != nil {
+			return err
+		}
+		tailer.Close()
+	}
+	if tailer.file == nil {
+		if err := tailer.open(info, identity); err != nil {
+			return err
+		}
+	}
+	if info.Size() < tailer.offset {
+		// Truncated in place, e.g: by logrotate copytruncate
+		tailer.offset = 0
+		tailer.partial = nil
+	}
+	return tailer.read(send)
+}
+
+func (tailer *Tailer) open(info os.FileInfo, identity fileIdentity) error {
+	file, err := os.Open(tailer.Path)
+	if err != nil {
+		return err
+	}
+	tailer.file = file
+	tailer.identity = identity
+	tailer.partial = nil
+	switch {
+	case tailer.opened:
+		// Recreated after a rotation, all of it is new
+		tailer.offset = 0
+	case tailer.saved != nil && tailer.saved.fileIdentity == identity && tailer.saved.Offset <= info.Size():
+		tailer.offset = tailer.saved.Offset
+	case tailer.saved != nil:
+		// Rotated while the agent was not running
+		tailer.offset = 0
+	default:
+		tailer.offset = info.Size()
+	}
+	tailer.opened = true
+	return nil
+}
+
+// read ships the complete lines between the offset and the end of the file, stopping after MAX_POLL_BYTES
+func (tailer *Tailer) read(send func(line string) error) error {
+	buffer := make([]byte, READ_BUFFER_BYTES)
+	read := 0
+	for {
+		if read >= MAX_POLL_BYTES {
+			tailer.behind = true
+			return nil
+		}
+		n, err := tailer.file.ReadAt(buffer, tailer.offset+int64(len(tailer.partial)))
+		read += n
+		if n > 0 {
+			if sendErr := tailer.consume(buffer[:n], send); sendErr != nil {
+				return sendErr
+			}
+		}
+		if err == io.EOF || n == 0 {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// consume ships the complete lines of data, keeping the trailing incomplete one. The offset only moves past the
+// lines which have been shipped.
+func (tailer *Tailer) consume(data []byte, send func(line string) error) error {
+	tailer.partial = append(tailer.partial, data...)
+	for {
+		index := bytes.IndexByte(tailer.partial, '\n')
+		if index < 0 {
+			break
+		}
+		if err := tailer.ship(tailer.partial[:index], index+1, send); err != nil {
+			return err
+		}
+	}
+	for len(tailer.partial) >= MAX_LINE_BYTES {
+		if err := tailer.ship(tailer.partial[:MAX_LINE_BYTES], MAX_LINE_BYTES, send); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tailer *Tailer) ship(line []byte, consumed int, send func(line string) error) error {
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	if len(line) > 0 {
+		if err := send(string(line)); err != nil {
+			return err
+		}
+	}
+	tailer.partial = tailer.partial[consumed:]
+	tailer.offset += int64(consumed)
+	return nil
+}
+
+func (tailer *Tailer) flushPartial(send func(line string) error) error {
+	if len(tailer.partial) == 0 {
+		return nil
+	}
+	return tailer.ship(tailer.partial, len(tailer.partial), send)
+}
+
+// Close closes the followed file
+func (tailer *Tailer) Close() {
+	if tailer.file != nil {
+		tailer.file.Close()
+		tailer.file = nil
+	}
+}
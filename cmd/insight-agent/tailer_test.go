@@ -0,0 +1,119 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTailerTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "insight-agent")
+	assert.Nil(t, err)
+	return dir
+}
+
+func appendTailerTestFile(t *testing.T, path, content string) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	assert.Nil(t, err)
+	_, err = file.WriteString(content)
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+}
+
+func pollTailerTest(t *testing.T, tailer *Tailer) []string {
+	var lines []string
+	assert.Nil(t, tailer.Poll(func(line string) error {
+		lines = append(lines, line)
+		return nil
+	}))
+	return lines
+}
+
+func TestTailer_StartsAtEndAndHoldsPartialLines(t *testing.T) {
+	dir := newTailerTestDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	appendTailerTestFile(t, path, "history\n")
+
+	tailer := NewTailer(path, "token", nil)
+	defer tailer.Close()
+	assert.Nil(t, pollTailerTest(t, tailer))
+
+	appendTailerTestFile(t, path, "first\r\nsec")
+	assert.Equal(t, []string{"first"}, pollTailerTest(t, tailer))
+	assert.Equal(t, int64(15), tailer.Offset().Offset)
+	appendTailerTestFile(t, path, "ond\n")
+	assert.Equal(t, []string{"second"}, pollTailerTest(t, tailer))
+}
+
+func TestTailer_PollIsBounded(t *testing.T) {
+	dir := newTailerTestDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	appendTailerTestFile(t, path, "")
+
+	tailer := NewTailer(path, "token", nil)
+	defer tailer.Close()
+	assert.Nil(t, pollTailerTest(t, tailer))
+
+	line := strings.Repeat("x", 1023) + "\n"
+	appendTailerTestFile(t, path, strings.Repeat(line, MAX_POLL_BYTES/len(line)+10))
+	assert.Len(t, pollTailerTest(t, tailer), MAX_POLL_BYTES/len(line))
+	assert.True(t, tailer.Behind())
+	assert.Len(t, pollTailerTest(t, tailer), 10)
+	assert.False(t, tailer.Behind())
+}
+
+func TestTailer_Rotation(t *testing.T) {
+	dir := newTailerTestDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	appendTailerTestFile(t, path, "")
+
+	tailer := NewTailer(path, "token", nil)
+	defer tailer.Close()
+	assert.Nil(t, pollTailerTest(t, tailer))
+
+	appendTailerTestFile(t, path, "before rotation\nunterminated")
+	assert.Nil(t, os.Rename(path, path+".1"))
+	assert.Equal(t, []string{"before rotation"}, pollTailerTest(t, tailer))
+	appendTailerTestFile(t, path, "after rotation\n")
+	assert.Equal(t, []string{"unterminated", "after rotation"}, pollTailerTest(t, tailer))
+}
+
+func TestTailer_Truncation(t *testing.T) {
+	dir := newTailerTestDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	appendTailerTestFile(t, path, "")
+
+	tailer := NewTailer(path, "token", nil)
+	defer tailer.Close()
+	assert.Nil(t, pollTailerTest(t, tailer))
+	appendTailerTestFile(t, path, "a long line before truncation\n")
+	assert.Equal(t, []string{"a long line before truncation"}, pollTailerTest(t, tailer))
+
+	assert.Nil(t, os.Truncate(path, 0))
+	appendTailerTestFile(t, path, "short\n")
+	assert.Equal(t, []string{"short"}, pollTailerTest(t, tailer))
+}
+
+func TestTailer_ResumesFromSavedOffset(t *testing.T) {
+	dir := newTailerTestDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	appendTailerTestFile(t, path, "shipped\n")
+
+	tailer := NewTailer(path, "token", &FileOffset{})
+	assert.Equal(t, []string{"shipped"}, pollTailerTest(t, tailer))
+	saved := tailer.Offset()
+	tailer.Close()
+
+	appendTailerTestFile(t, path, "written while stopped\n")
+	tailer = NewTailer(path, "token", &saved)
+	defer tailer.Close()
+	assert.Equal(t, []string{"written while stopped"}, pollTailerTest(t, tailer))
+}
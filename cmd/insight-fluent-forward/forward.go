@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+const (
+	// EVENT_TIME_EXT_TYPE is the msgpack extension type of the Forward protocol EventTime
+	EVENT_TIME_EXT_TYPE = 0
+	COMPRESSED_GZIP     = "gzip"
+)
+
+// ForwardEntry is a single record of a Forward message
+type ForwardEntry struct {
+	Time   time.Time
+	Record map[string]interface{}
+}
+
+// ForwardMessage is a message of the Fluent Forward protocol, whatever its mode: Message, Forward or PackedForward
+type ForwardMessage struct {
+	Tag     string
+	Entries []ForwardEntry
+	// Chunk is set when the sender expects an acknowledgement
+	Chunk string
+}
+
+// ParseForwardMessage interprets a msgpack value received from a Forward client:
+//   - Message mode: [tag, time, record, option?]
+//   - Forward mode: [tag, [[time, record], ...], option?]
+//   - PackedForward mode: [tag, bin or str of concatenated [time, record] entries, option?], possibly gzip compressed
+func ParseForwardMessage(value interface{}) (*ForwardMessage, error) {
+	array, ok := value.([]interface{})
+	if !ok || len(array) < 2 {
+		return nil, fmt.Errorf("Invalid forward message, an array of at least 2 elements is expected")
+	}
+	tag, ok := array[0].(string)
+	if !ok || tag == "" {
+		return nil, fmt.Errorf("Invalid forward message, missing tag")
+	}
+	message := &ForwardMessage{Tag: tag}
+
+	var optionIndex int
+	switch entries := array[1].(type) {
+	case []interface{}:
+		optionIndex = 2
+		for _, entry := range entries {
+			parsed, err := parseForwardEntry(entry)
+			if err != nil {
+				return nil, err
+			}
+			message.Entries = append(message.Entries, parsed)
+		}
+	case []byte, string:
+		optionIndex = 2
+		options, _ := optionAt(array, optionIndex)
+		packed, err := unpackEntries(entries, options)
+		if err != nil {
+			return nil, err
+		}
+		message.Entries = packed
+	default:
+		if len(array) < 3 {
+			return nil, fmt.Errorf("Invalid forward message, missing record")
+		}
+		optionIndex = 3
+		entry, err := parseForwardEntry([]interface{}{array[1], array[2]})
+		if err != nil {
+			return nil, err
+		}
+		message.Entries = []ForwardEntry{entry}
+	}
+
+	options, err := optionAt(array, optionIndex)
+	if err != nil {
+		return nil, err
+	}
+	if chunk, ok := options["chunk"]; ok {
+		if message.Chunk, ok = chunk.(string); !ok {
+			return nil, fmt.Errorf("Invalid forward message, chunk option must be a string")
+		}
+	}
+	return message, nil
+}
+
+// ForwardChunk returns the chunk option of a msgpack value received from a Forward client, even when it is not a valid
+// Forward message, so that invalid messages can be acknowledged and dropped rather than sent again forever
+func ForwardChunk(value interface{}) string {
+	array, ok := value.([]interface{})
+	if !ok || len(array) < 3 {
+		return ""
+	}
+	options, ok := array[len(array)-1].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	chunk, _ := options["chunk"].(string)
+	return chunk
+}
+
+func optionAt(array []interface{}, index int) (map[string]interface{}, error) {
+	if len(array) <= index || array[index] == nil {
+		return nil, nil
+	}
+	options, ok := array[index].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid forward message, option must be a map")
+	}
+	return options, nil
+}
+
+// unpackEntries decodes the msgpack stream of a PackedForward message
+func unpackEntries(packed interface{}, options map[string]interface{}) ([]ForwardEntry, error) {
+	var data []byte
+	switch typed := packed.(type) {
+	case []byte:
+		data = typed
+	case string:
+		data = []byte(typed)
+	}
+	var reader io.Reader = bytes.NewReader(data)
+	if options["compressed"] == COMPRESSED_GZIP {
+		// Several gzip members may be concatenated, gzip.Reader reads them all
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		uncompressed, err := ioutil.ReadAll(io.LimitReader(gzipReader, MAX_MSGPACK_BYTES+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(uncompressed) > MAX_MSGPACK_BYTES {
+			return nil, fmt.Errorf("Invalid forward message, uncompressed entries exceed %d bytes", MAX_MSGPACK_BYTES)
+		}
+		reader = bytes.NewReader(uncompressed)
+	}
+	var entries []ForwardEntry
+	bufferedReader := bufio.NewReader(reader)
+	for {
+		value, err := DecodeMsgpack(bufferedReader)
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		entry, err := parseForwardEntry(value)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// parseForwardEntry parses a [time, record] entry
+func parseForwardEntry(value interface{}) (ForwardEntry, error) {
+	array, ok := value.([]interface{})
+	if !ok || len(array) != 2 {
+		return ForwardEntry{}, fmt.Errorf("Invalid forward entry, a [time, record] array is expected")
+	}
+	eventTime, err := parseEventTime(array[0])
+	if err != nil {
+		return ForwardEntry{}, err
+	}
+	record, ok := array[1].(map[string]interface{})
+	if !ok {
+		return ForwardEntry{}, fmt.Errorf("Invalid forward entry, the record must be a map")
+	}
+	return ForwardEntry{Time: eventTime, Record: record}, nil
+}
+
+// parseEventTime parses either an integer unix time or an EventTime extension with nanosecond precision
+func parseEventTime(value interface{}) (time.Time, error) {
+	switch typed := value.(type) {
+	case int64:
+		return time.Unix(typed, 0), nil
+	case uint64:
+		return time.Unix(int64(typed), 0), nil
+	case float64:
+		seconds := int64(typed)
+		return time.Unix(seconds, int64((typed-float64(seconds))*float64(time.Second))), nil
+	case Ext:
+		if typed.Type != EVENT_TIME_EXT_TYPE || len(typed.Data) != 8 {
+			return time.Time{}, fmt.Errorf("Invalid forward entry, unknown time extension %d", typed.Type)
+		}
+		seconds := binary.BigEndian.Uint32(typed.Data[0:4])
+		nanoseconds := binary.BigEndian.Uint32(typed.Data[4:8])
+		return time.Unix(int64(seconds), int64(nanoseconds)), nil
+	}
+	return time.Time{}, fmt.Errorf("Invalid forward entry, unsupported time %T", value)
+}
+
+// EncodeAck returns the response acknowledging the chunk of a message
+func EncodeAck(chunk string) ([]byte, error) {
+	return EncodeMsgpack(nil, map[string]interface{}{"ack": chunk})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func eventTime(seconds, nanoseconds uint32) Ext {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint32(data[0:4], seconds)
+	binary.BigEndian.PutUint32(data[4:8], nanoseconds)
+	return Ext{Type: EVENT_TIME_EXT_TYPE, Data: data}
+}
+
+func TestParseForwardMessage_MessageMode(t *testing.T) {
+	message, err := ParseForwardMessage([]interface{}{
+		"app.web", eventTime(1500000000, 42), map[string]interface{}{"log": "hello"},
+		map[string]interface{}{"chunk": "abc"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "app.web", message.Tag)
+	assert.Equal(t, "abc", message.Chunk)
+	assert.Equal(t, []ForwardEntry{{Time: time.Unix(1500000000, 42), Record: map[string]interface{}{"log": "hello"}}},
+		message.Entries)
+}
+
+func TestParseForwardMessage_ForwardMode(t *testing.T) {
+	message, err := ParseForwardMessage([]interface{}{"app.web", []interface{}{
+		[]interface{}{int64(1500000000), map[string]interface{}{"log": "first"}},
+		[]interface{}{eventTime(1500000001, 0), map[string]interface{}{"log": "second"}},
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, "", message.Chunk)
+	assert.Equal(t, []ForwardEntry{
+		{Time: time.Unix(1500000000, 0), Record: map[string]interface{}{"log": "first"}},
+		{Time: time.Unix(1500000001, 0), Record: map[string]interface{}{"log": "second"}},
+	}, message.Entries)
+}
+
+func TestParseForwardMessage_PackedForwardMode(t *testing.T) {
+	packed := encodeTestMsgpack(t,
+		[]interface{}{int64(1500000000), map[string]interface{}{"log": "first"}},
+		[]interface{}{int64(1500000001), map[string]interface{}{"log": "second"}},
+	)
+	expected := []ForwardEntry{
+		{Time: time.Unix(1500000000, 0), Record: map[string]interface{}{"log": "first"}},
+		{Time: time.Unix(1500000001, 0), Record: map[string]interface{}{"log": "second"}},
+	}
+
+	message, err := ParseForwardMessage([]interface{}{"app.web", packed, map[string]interface{}{"chunk": "abc"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", message.Chunk)
+	assert.Equal(t, expected, message.Entries)
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write(packed)
+	writer.Close()
+	message, err = ParseForwardMessage([]interface{}{"app.web", compressed.Bytes(),
+		map[string]interface{}{"compressed": COMPRESSED_GZIP}})
+	assert.Nil(t, err)
+	assert.Equal(t, expected, message.Entries)
+}
+
+func TestParseForwardMessage_Invalid(t *testing.T) {
+	for _, value := range []interface{}{
+		"not an array",
+		[]interface{}{"app.web"},
+		[]interface{}{int64(1), int64(1500000000), map[string]interface{}{}},
+		[]interface{}{"app.web", int64(1500000000)},
+		[]interface{}{"app.web", int64(1500000000), "not a record"},
+		[]interface{}{"app.web", Ext{Type: 1, Data: make([]byte, 8)}, map[string]interface{}{}},
+		[]interface{}{"app.web", []interface{}{[]interface{}{int64(1500000000)}}},
+		[]interface{}{"app.web", []byte{0xc1}},
+		[]interface{}{"app.web", int64(1500000000), map[string]interface{}{}, map[string]interface{}{"chunk": int64(1)}},
+	} {
+		_, err := ParseForwardMessage(value)
+		assert.NotNil(t, err, "%v", value)
+	}
+}
+
+func TestForwardChunk(t *testing.T) {
+	assert.Equal(t, "abc", ForwardChunk([]interface{}{"app.web", int64(1), "not a record", map[string]interface{}{"chunk": "abc"}}))
+	assert.Equal(t, "abc", ForwardChunk([]interface{}{"app.web", []byte{0xc1}, map[string]interface{}{"chunk": "abc"}}))
+	assert.Equal(t, "", ForwardChunk([]interface{}{"app.web", int64(1), map[string]interface{}{"log": "record"}}))
+	assert.Equal(t, "", ForwardChunk("not an array"))
+}
@@ -0,0 +1,80 @@
+// Command insight-fluent-forward receives the records of Fluentd and fluent-bit over the Forward protocol and ships them,
+// encoded as JSON, to the insight logs named after their tag. The Message, Forward and PackedForward modes are
+// supported, gzip compressed or not, and messages holding a chunk option are acknowledged once their records have been
+// written to the connection to insight. The logs are looked up in a single logset; records whose tag matches no log
+// are shipped to a default log. The handshake of the secure forward mode is not supported.
+//
+// The api key is read from the INSIGHT_API_KEY environment variable.
+//
+//	insight-fluent-forward -region eu -logset Containers -default-log Unmatched -route kube.web=Web
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	insight "github.com/Tweddle-SE-Team/insight_goclient"
+	"github.com/Tweddle-SE-Team/insight_goclient/internal/forwarding"
+)
+
+func main() {
+	routes := forwarding.RoutesFlag{}
+	region := flag.String("region", os.Getenv(insight.REGION_ENV_VARIABLE), "insight region of the account")
+	logsetName := flag.String("logset", "", "logset holding the logs records are shipped to")
+	defaultLog := flag.String("default-log", "", "log of the logset receiving the records of unmatched tags")
+	address := flag.String("listen", ":24224", "tcp address to listen on")
+	flag.Var(routes, "route", "tag=log mapping overriding the log a tag is shipped to, may be repeated")
+	flag.Parse()
+
+	if *logsetName == "" || *defaultLog == "" {
+		log.Fatal("-logset and -default-log are mandatory")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	client, err := insight.NewInsightClientWithOptions("", *region, insight.WithCredentials(&insight.EnvCredentials{}))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defaultToken, err := client.GetLogTokenContext(ctx, *logsetName, *defaultLog)
+	if err != nil {
+		log.Fatalf("Unable to get the token of the default log %s: %s", *defaultLog, err)
+	}
+	shipper, err := insight.NewShipper(*region, defaultToken)
+	if err != nil {
+		log.Fatal(err)
+	}
+	receiver := &Receiver{
+		Router: &forwarding.Router{
+			Resolver:     forwarding.NewInsightResolver(client, *logsetName),
+			DefaultToken: defaultToken,
+			Routes:       routes,
+		},
+		Sender: shipper,
+	}
+
+	listener, err := net.Listen("tcp", *address)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Listening for forward messages on tcp %s", listener.Addr())
+	if err := receiver.ServeTCP(ctx, listener); err != nil {
+		log.Printf("tcp listener stopped: %s", err)
+	}
+
+	if err := shipper.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+const (
+	// MAX_MSGPACK_BYTES bounds the size of a single string, binary or extension value
+	MAX_MSGPACK_BYTES = 16 * 1024 * 1024
+	// MAX_MSGPACK_ELEMENTS bounds the number of elements of a single array or map
+	MAX_MSGPACK_ELEMENTS = 1024 * 1024
+	// MAX_MSGPACK_DEPTH bounds the nesting of arrays and maps
+	MAX_MSGPACK_DEPTH = 100
+	// MAX_MSGPACK_PREALLOCATED bounds the capacity allocated for an array, map, string or binary before its content is
+	// read, the announced length being untrusted
+	MAX_MSGPACK_PREALLOCATED = 1024
+)
+
+// Ext is a msgpack extension value
+type Ext struct {
+	Type int8
+	Data []byte
+}
+
+// DecodeMsgpack reads the next msgpack value. Integers are decoded as int64, or uint64 when they do not fit, floats as
+// float64, strings as string, binaries as []byte, arrays as []interface{} and maps as map[string]interface{}, keys
+// which are not strings being formatted with fmt.
+func DecodeMsgpack(reader *bufio.Reader) (interface{}, error) {
+	return decodeMsgpack(reader, 0)
+}
+
+func decodeMsgpack(reader *bufio.Reader, depth int) (interface{}, error) {
+	if depth > MAX_MSGPACK_DEPTH {
+		return nil, fmt.Errorf("msgpack value nested too deeply")
+	}
+	code, err := reader.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	case code&0xf0 == 0x80:
+		return decodeMsgpackMap(reader, int(code&0x0f), depth)
+	case code&0xf0 == 0x90:
+		return decodeMsgpackArray(reader, int(code&0x0f), depth)
+	case code&0xe0 == 0xa0:
+		return readMsgpackString(reader, int(code&0x1f))
+	}
+	switch code {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		length, err := readMsgpackLength(reader, code-0xc4, MAX_MSGPACK_BYTES)
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackBytes(reader, length)
+	case 0xc7, 0xc8, 0xc9:
+		length, err := readMsgpackLength(reader, code-0xc7, MAX_MSGPACK_BYTES)
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackExt(reader, length)
+	case 0xca:
+		bits, err := readMsgpackUint(reader, 4)
+		return float64(math.Float32frombits(uint32(bits))), err
+	case 0xcb:
+		bits, err := readMsgpackUint(reader, 8)
+		return math.Float64frombits(bits), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		value, err := readMsgpackUint(reader, 1<<(code-0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if value > math.MaxInt64 {
+			return value, nil
+		}
+		return int64(value), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (code - 0xd0)
+		value, err := readMsgpackUint(reader, size)
+		if err != nil {
+			return nil, err
+		}
+		// Sign extend the value read
+		shift := uint(64 - 8*size)
+		return int64(value<<shift) >> shift, nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return readMsgpackExt(reader, 1<<(code-0xd4))
+	case 0xd9, 0xda, 0xdb:
+		length, err := readMsgpackLength(reader, code-0xd9, MAX_MSGPACK_BYTES)
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackString(reader, length)
+	case 0xdc, 0xdd:
+		length, err := readMsgpackLength(reader, code-0xdc+1, MAX_MSGPACK_ELEMENTS)
+		if err != nil {
+			return nil, err
+		}
+		return decodeMsgpackArray(reader, length, depth)
+	case 0xde, 0xdf:
+		length, err := readMsgpackLength(reader, code-0xde+1, MAX_MSGPACK_ELEMENTS)
+		if err != nil {
+			return nil, err
+		}
+		return decodeMsgpackMap(reader, length, depth)
+	}
+	return nil, fmt.Errorf("Invalid msgpack type 0x%x", code)
+}
+
+func decodeMsgpackArray(reader *bufio.Reader, length int, depth int) (interface{}, error) {
+	array := make([]interface{}, 0, preallocated(length))
+	for i := 0; i < length; i++ {
+		element, err := decodeMsgpack(reader, depth+1)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		array = append(array, element)
+	}
+	return array, nil
+}
+
+func decodeMsgpackMap(reader *bufio.Reader, length int, depth int) (interface{}, error) {
+	result := make(map[string]interface{}, preallocated(length))
+	for i := 0; i < length; i++ {
+		key, err := decodeMsgpack(reader, depth+1)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		value, err := decodeMsgpack(reader, depth+1)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		switch typedKey := key.(type) {
+		case string:
+			result[typedKey] = value
+		case []byte:
+			result[string(typedKey)] = value
+		default:
+			result[fmt.Sprint(typedKey)] = value
+		}
+	}
+	return result, nil
+}
+
+func preallocated(length int) int {
+	if length > MAX_MSGPACK_PREALLOCATED {
+		return MAX_MSGPACK_PREALLOCATED
+	}
+	return length
+}
+
+// readMsgpackLength reads a length stored on 1, 2 or 4 bytes depending on sizeClass (0, 1 or 2), rejecting lengths
+// greater than max before they are converted to int, which may not hold them
+func readMsgpackLength(reader *bufio.Reader, sizeClass byte, max int) (int, error) {
+	length, err := readMsgpackUint(reader, 1<<sizeClass)
+	if err != nil {
+		return 0, err
+	}
+	if length > uint64(max) {
+		return 0, fmt.Errorf("msgpack length %d exceeds the limit of %d", length, max)
+	}
+	return int(length), nil
+}
+
+func readMsgpackUint(reader *bufio.Reader, size int) (uint64, error) {
+	buffer := make([]byte, 8)
+	if _, err := io.ReadFull(reader, buffer[8-size:]); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	return binary.BigEndian.Uint64(buffer), nil
+}
+
+// readMsgpackBytes reads length bytes, growing the returned slice as they arrive rather than trusting length upfront
+func readMsgpackBytes(reader *bufio.Reader, length int) ([]byte, error) {
+	data := bytes.NewBuffer(make([]byte, 0, preallocated(length)))
+	if _, err := io.CopyN(data, reader, int64(length)); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return data.Bytes(), nil
+}
+
+func readMsgpackString(reader *bufio.Reader, length int) (interface{}, error) {
+	data, err := readMsgpackBytes(reader, length)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func readMsgpackExt(reader *bufio.Reader, length int) (interface{}, error) {
+	extType, err := reader.ReadByte()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	data, err := readMsgpackBytes(reader, length)
+	if err != nil {
+		return nil, err
+	}
+	return Ext{Type: int8(extType), Data: data}, nil
+}
+
+// unexpectedEOF reports a value cut in the middle as such rather than as the clean end of the stream
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// EncodeMsgpack appends the msgpack encoding of value to buffer. Only the types returned by DecodeMsgpack, along with
+// int, are supported; map keys are sorted to keep the encoding stable.
+func EncodeMsgpack(buffer []byte, value interface{}) ([]byte, error) {
+	switch typed := value.(type) {
+	case nil:
+		return append(buffer, 0xc0), nil
+	case bool:
+		if typed {
+			return append(buffer, 0xc3), nil
+		}
+		return append(buffer, 0xc2), nil
+	case int:
+		return encodeMsgpackInt(buffer, int64(typed)), nil
+	case int64:
+		return encodeMsgpackInt(buffer, typed), nil
+	case uint64:
+		if typed <= math.MaxInt64 {
+			return encodeMsgpackInt(buffer, int64(typed)), nil
+		}
+		return appendMsgpackUint(append(buffer, 0xcf), typed, 8), nil
+	case float64:
+		return appendMsgpackUint(append(buffer, 0xcb), math.Float64bits(typed), 8), nil
+	case string:
+		return append(encodeMsgpackHeader(buffer, len(typed), 0xa0, 32, [3]byte{0xd9, 0xda, 0xdb}), typed...), nil
+	case []byte:
+		return append(encodeMsgpackHeader(buffer, len(typed), 0, 0, [3]byte{0xc4, 0xc5, 0xc6}), typed...), nil
+	case Ext:
+		buffer = encodeMsgpackHeader(buffer, len(typed.Data), 0, 0, [3]byte{0xc7, 0xc8, 0xc9})
+		return append(append(buffer, byte(typed.Type)), typed.Data...), nil
+	case []interface{}:
+		buffer = encodeMsgpackHeader(buffer, len(typed), 0x90, 16, [3]byte{0, 0xdc, 0xdd})
+		var err error
+		for _, element := range typed {
+			if buffer, err = EncodeMsgpack(buffer, element); err != nil {
+				return nil, err
+			}
+		}
+		return buffer, nil
+	case map[string]interface{}:
+		buffer = encodeMsgpackHeader(buffer, len(typed), 0x80, 16, [3]byte{0, 0xde, 0xdf})
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var err error
+		for _, key := range keys {
+			if buffer, err = EncodeMsgpack(buffer, key); err != nil {
+				return nil, err
+			}
+			if buffer, err = EncodeMsgpack(buffer, typed[key]); err != nil {
+				return nil, err
+			}
+		}
+		return buffer, nil
+	}
+	return nil, fmt.Errorf("Unsupported msgpack type %T", value)
+}
+
+func encodeMsgpackInt(buffer []byte, value int64) []byte {
+	switch {
+	case value >= 0 && value <= 0x7f:
+		return append(buffer, byte(value))
+	case value < 0 && value >= -32:
+		return append(buffer, byte(int8(value)))
+	case value >= math.MinInt8 && value <= math.MaxInt8:
+		return appendMsgpackUint(append(buffer, 0xd0), uint64(value), 1)
+	case value >= math.MinInt16 && value <= math.MaxInt16:
+		return appendMsgpackUint(append(buffer, 0xd1), uint64(value), 2)
+	case value >= math.MinInt32 && value <= math.MaxInt32:
+		return appendMsgpackUint(append(buffer, 0xd2), uint64(value), 4)
+	}
+	return appendMsgpackUint(append(buffer, 0xd3), uint64(value), 8)
+}
+
+// encodeMsgpackHeader appends the type and length of a value. Lengths lower than fixLimit use the fix format
+// fixCode|length, longer ones the codes of the 8, 16 and 32 bit formats; types without an 8 bit format have a zero
+// code instead.
+func encodeMsgpackHeader(buffer []byte, length int, fixCode byte, fixLimit int, sizedCodes [3]byte) []byte {
+	switch {
+	case length < fixLimit:
+		return append(buffer, fixCode|byte(length))
+	case length <= math.MaxUint8 && sizedCodes[0] != 0:
+		return appendMsgpackUint(append(buffer, sizedCodes[0]), uint64(length), 1)
+	case length <= math.MaxUint16:
+		return appendMsgpackUint(append(buffer, sizedCodes[1]), uint64(length), 2)
+	}
+	return appendMsgpackUint(append(buffer, sizedCodes[2]), uint64(length), 4)
+}
+
+func appendMsgpackUint(buffer []byte, value uint64, size int) []byte {
+	encoded := make([]byte, 8)
+	binary.BigEndian.PutUint64(encoded, value)
+	return append(buffer, encoded[8-size:]...)
+}
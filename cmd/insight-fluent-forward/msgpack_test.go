@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"runtime"
+	"testing"
+)
+
+func encodeTestMsgpack(t *testing.T, values ...interface{}) []byte {
+	var buffer []byte
+	var err error
+	for _, value := range values {
+		buffer, err = EncodeMsgpack(buffer, value)
+		assert.Nil(t, err)
+	}
+	return buffer
+}
+
+func TestMsgpack_RoundTrip(t *testing.T) {
+	values := []interface{}{
+		nil, true, false, int64(0), int64(127), int64(-32), int64(-33), int64(255), int64(-129), int64(65536),
+		int64(-1 << 40), uint64(1 << 63), 1.5, "", "short", string(make([]byte, 300)), []byte{1, 2, 3},
+		Ext{Type: 5, Data: []byte{1, 2, 3, 4}},
+		[]interface{}{int64(1), "two", []interface{}{}},
+		map[string]interface{}{"key": "value", "nested": map[string]interface{}{"list": []interface{}{int64(70000)}}},
+	}
+	reader := bufio.NewReader(bytes.NewReader(encodeTestMsgpack(t, values...)))
+	for _, expected := range values {
+		value, err := DecodeMsgpack(reader)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, value)
+	}
+}
+
+func TestMsgpack_DecodeTruncated(t *testing.T) {
+	encoded := encodeTestMsgpack(t, []interface{}{"tag", int64(1)})
+	_, err := DecodeMsgpack(bufio.NewReader(bytes.NewReader(encoded[:len(encoded)-1])))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	_, err = DecodeMsgpack(bufio.NewReader(bytes.NewReader(nil)))
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestMsgpack_DecodeDoesNotTrustLengths(t *testing.T) {
+	// Nested arrays announcing a million elements each, but cut right after their headers
+	payload := bytes.Repeat([]byte{0xdd, 0x00, 0x0f, 0xff, 0xff}, 20)
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	_, err := DecodeMsgpack(bufio.NewReader(bytes.NewReader(payload)))
+	runtime.ReadMemStats(&after)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	assert.True(t, after.TotalAlloc-before.TotalAlloc < 10*1024*1024, "allocated %d bytes", after.TotalAlloc-before.TotalAlloc)
+
+	payload = bytes.Repeat([]byte{0xdf, 0x00, 0x0f, 0xff, 0xff, 0xa1, 'k'}, 20)
+	runtime.ReadMemStats(&before)
+	_, err = DecodeMsgpack(bufio.NewReader(bytes.NewReader(payload)))
+	runtime.ReadMemStats(&after)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	assert.True(t, after.TotalAlloc-before.TotalAlloc < 10*1024*1024, "allocated %d bytes", after.TotalAlloc-before.TotalAlloc)
+
+	// Binaries announcing 16 MiB each, but cut right after their headers
+	payload = []byte{0x92, 0xc6, 0x00, 0xff, 0xff, 0xff}
+	runtime.ReadMemStats(&before)
+	_, err = DecodeMsgpack(bufio.NewReader(bytes.NewReader(payload)))
+	runtime.ReadMemStats(&after)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	assert.True(t, after.TotalAlloc-before.TotalAlloc < 1024*1024, "allocated %d bytes", after.TotalAlloc-before.TotalAlloc)
+}
+
+func TestMsgpack_DecodeRejectsLengthsAboveTheLimits(t *testing.T) {
+	for _, payload := range [][]byte{
+		{0xc6, 0xff, 0xff, 0xff, 0xff},
+		{0xc9, 0xff, 0xff, 0xff, 0xff},
+		{0xdb, 0xff, 0xff, 0xff, 0xff},
+		{0xdd, 0xff, 0xff, 0xff, 0xff},
+		{0xdf, 0xff, 0xff, 0xff, 0xff},
+	} {
+		_, err := DecodeMsgpack(bufio.NewReader(bytes.NewReader(payload)))
+		assert.NotNil(t, err)
+		assert.NotEqual(t, io.ErrUnexpectedEOF, err)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"time"
+
+	"github.com/Tweddle-SE-Team/insight_goclient/internal/forwarding"
+)
+
+const (
+	TCP_READ_TIMEOUT  = 10 * time.Minute
+	ACK_WRITE_TIMEOUT = 30 * time.Second
+	// ACK_FLUSH_TIMEOUT bounds the wait for the records of a message to be flushed before acknowledging it; the
+	// connection is closed when they are not, so that the client sends them again
+	ACK_FLUSH_TIMEOUT = time.Minute
+	// TIME_FIELD is added to the records which do not hold it already, set to the time of their event
+	TIME_FIELD = "time"
+)
+
+// Sender is the part of the insight Shipper used by the receiver
+type Sender interface {
+	forwarding.Sender
+	Flush(ctx context.Context) error
+}
+
+// Receiver ships the records of Forward messages, as JSON, to the insight log named after their tag, see
+// forwarding.Router
+type Receiver struct {
+	*forwarding.Router
+	Sender Sender
+}
+
+// Handle ships the records of message to the log of its tag. Records which cannot be encoded as JSON are dropped; an
+// error is only returned when the records could not be handed to the sender.
+func (receiver *Receiver) Handle(ctx context.Context, message *ForwardMessage) error {
+	token := receiver.Token(ctx, message.Tag)
+	for _, entry := range message.Entries {
+		line, err := FormatRecord(entry)
+		if err != nil {
+			log.Printf("Dropping a record tagged %s: %s", message.Tag, err)
+			continue
+		}
+		if err := receiver.Sender.SendWithToken(token, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FormatRecord encodes the record of entry as JSON, adding the time of the event unless the record already holds one
+func FormatRecord(entry ForwardEntry) (string, error) {
+	record := normalizeValue(entry.Record).(map[string]interface{})
+	if _, ok := record[TIME_FIELD]; !ok {
+		record[TIME_FIELD] = entry.Time.UTC().Format(time.RFC3339Nano)
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	return string(line), nil
+}
+
+// normalizeValue converts the msgpack binaries, which fluent-bit uses for strings, to strings so that they are not
+// encoded as base64 in JSON
+func normalizeValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case []byte:
+		return string(typed)
+	case []interface{}:
+		normalized := make([]interface{}, len(typed))
+		for i, element := range typed {
+			normalized[i] = normalizeValue(element)
+		}
+		return normalized
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(typed))
+		for key, element := range typed {
+			normalized[key] = normalizeValue(element)
+		}
+		return normalized
+	}
+	return value
+}
+
+// ServeTCP receives the Forward messages of the connections accepted by listener until ctx is done
+func (receiver *Receiver) ServeTCP(ctx context.Context, listener net.Listener) error {
+	return forwarding.ServeTCP(ctx, listener, receiver.serveConn)
+}
+
+// serveConn handles the messages of a connection. Messages holding a chunk option are only acknowledged once their
+// records have been flushed, i.e. written to the connection to insight: they can no longer be dropped by the shipper,
+// though insight does not confirm their reception. Invalid messages are acknowledged too so that the client drops them
+// instead of sending them again. The connection is closed on malformed msgpack as the stream cannot be resynchronized.
+func (receiver *Receiver) serveConn(ctx context.Context, conn net.Conn) {
+	peer := conn.RemoteAddr().String()
+	reader := bufio.NewReader(conn)
+	for {
+		conn.SetReadDeadline(time.Now().Add(TCP_READ_TIMEOUT))
+		value, err := DecodeMsgpack(reader)
+		if err != nil {
+			if err != io.EOF && ctx.Err() == nil {
+				log.Printf("Closing the connection from %s: %s", peer, err)
+			}
+			return
+		}
+		message, err := ParseForwardMessage(value)
+		if err != nil {
+			log.Printf("Dropping a message from %s: %s", peer, err)
+			if chunk := ForwardChunk(value); chunk != "" && !receiver.acknowledge(conn, chunk) {
+				return
+			}
+			continue
+		}
+		if err := receiver.Handle(ctx, message); err != nil {
+			log.Printf("Unable to ship the message tagged %s from %s: %s", message.Tag, peer, err)
+			continue
+		}
+		if message.Chunk == "" {
+			continue
+		}
+		if err := receiver.flush(ctx); err != nil {
+			log.Printf("Unable to flush chunk %s from %s, closing the connection: %s", message.Chunk, peer, err)
+			return
+		}
+		if !receiver.acknowledge(conn, message.Chunk) {
+			return
+		}
+	}
+}
+
+// acknowledge sends the ack of chunk, returning false when the connection must be closed
+func (receiver *Receiver) acknowledge(conn net.Conn, chunk string) bool {
+	ack, err := EncodeAck(chunk)
+	if err != nil {
+		log.Printf("Unable to acknowledge chunk %s from %s: %s", chunk, conn.RemoteAddr(), err)
+		return false
+	}
+	conn.SetWriteDeadline(time.Now().Add(ACK_WRITE_TIMEOUT))
+	_, err = conn.Write(ack)
+	return err == nil
+}
+
+func (receiver *Receiver) flush(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, ACK_FLUSH_TIMEOUT)
+	defer cancel()
+	return receiver.Sender.Flush(ctx)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tweddle-SE-Team/insight_goclient/internal/forwarding"
+)
+
+type testResolver struct {
+	tokens map[string]string
+}
+
+func (resolver *testResolver) LogNames(ctx context.Context) (map[string]bool, error) {
+	names := map[string]bool{}
+	for name := range resolver.tokens {
+		names[name] = true
+	}
+	return names, nil
+}
+
+func (resolver *testResolver) LogToken(ctx context.Context, logName string) (string, error) {
+	return resolver.tokens[logName], nil
+}
+
+type testSender struct {
+	mutex    sync.Mutex
+	lines    []string
+	flushed  int
+	flushErr error
+}
+
+func (sender *testSender) SendWithToken(token, line string) error {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+	sender.lines = append(sender.lines, token+" "+line)
+	return nil
+}
+
+func (sender *testSender) Flush(ctx context.Context) error {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+	if sender.flushErr != nil {
+		return sender.flushErr
+	}
+	sender.flushed = len(sender.lines)
+	return nil
+}
+
+func (sender *testSender) Flushed() int {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+	return sender.flushed
+}
+
+func (sender *testSender) Lines() []string {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+	return append([]string(nil), sender.lines...)
+}
+
+func TestReceiver_Handle(t *testing.T) {
+	sender := &testSender{}
+	receiver := &Receiver{
+		Router: &forwarding.Router{
+			Resolver:     &testResolver{tokens: map[string]string{"app.web": "web-token", "Workers": "workers-token"}},
+			DefaultToken: "default-token",
+			Routes:       map[string]string{"app.worker": "Workers"},
+		},
+		Sender: sender,
+	}
+	ctx := context.Background()
+	eventTime := time.Unix(1500000000, 42)
+	for _, tag := range []string{"app.web", "app.worker", "app.unknown"} {
+		assert.Nil(t, receiver.Handle(ctx, &ForwardMessage{Tag: tag, Entries: []ForwardEntry{
+			{Time: eventTime, Record: map[string]interface{}{"log": []byte("from " + tag)}},
+		}}))
+	}
+	assert.Nil(t, receiver.Handle(ctx, &ForwardMessage{Tag: "app.web", Entries: []ForwardEntry{
+		{Time: eventTime, Record: map[string]interface{}{"time": "custom", "level": int64(3)}},
+	}}))
+	assert.Equal(t, []string{
+		`web-token {"log":"from app.web","time":"2017-07-14T02:40:00.000000042Z"}`,
+		`workers-token {"log":"from app.worker","time":"2017-07-14T02:40:00.000000042Z"}`,
+		`default-token {"log":"from app.unknown","time":"2017-07-14T02:40:00.000000042Z"}`,
+		`web-token {"level":3,"time":"custom"}`,
+	}, sender.Lines())
+}
+
+func TestReceiver_ServeConn(t *testing.T) {
+	sender := &testSender{}
+	receiver := &Receiver{
+		Router: &forwarding.Router{
+			Resolver:     &testResolver{tokens: map[string]string{"app.web": "web-token"}},
+			DefaultToken: "default-token",
+		},
+		Sender: sender,
+	}
+	client, server := net.Pipe()
+	defer client.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go receiver.serveConn(ctx, server)
+
+	packed := encodeTestMsgpack(t, []interface{}{int64(1500000000), map[string]interface{}{"log": "packed"}})
+	go client.Write(encodeTestMsgpack(t,
+		[]interface{}{"app.web", int64(1500000000), map[string]interface{}{"log": "message"}},
+		[]interface{}{"app.web", []interface{}{
+			[]interface{}{int64(1500000000), map[string]interface{}{"log": "forward"}},
+		}, map[string]interface{}{"chunk": "first"}},
+		[]interface{}{"app.other", packed, map[string]interface{}{"chunk": "second"}},
+	))
+
+	reader := bufio.NewReader(client)
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for _, chunk := range []string{"first", "second"} {
+		ack, err := DecodeMsgpack(reader)
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]interface{}{"ack": chunk}, ack)
+	}
+	assert.Equal(t, 3, sender.Flushed())
+	assert.Equal(t, []string{
+		`web-token {"log":"message","time":"2017-07-14T02:40:00Z"}`,
+		`web-token {"log":"forward","time":"2017-07-14T02:40:00Z"}`,
+		`default-token {"log":"packed","time":"2017-07-14T02:40:00Z"}`,
+	}, sender.Lines())
+}
+
+func TestReceiver_ServeConnDoesNotAcknowledgeUnflushedChunks(t *testing.T) {
+	sender := &testSender{flushErr: fmt.Errorf("unreachable")}
+	receiver := &Receiver{
+		Router: &forwarding.Router{Resolver: &testResolver{}, DefaultToken: "default-token"},
+		Sender: sender,
+	}
+	client, server := net.Pipe()
+	defer client.Close()
+	done := make(chan struct{})
+	go func() {
+		receiver.serveConn(context.Background(), server)
+		server.Close()
+		close(done)
+	}()
+
+	go client.Write(encodeTestMsgpack(t,
+		[]interface{}{"app.web", int64(1500000000), map[string]interface{}{"log": "message"}, map[string]interface{}{"chunk": "first"}},
+	))
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err := DecodeMsgpack(bufio.NewReader(client))
+	assert.NotNil(t, err)
+	<-done
+	assert.Equal(t, []string{`default-token {"log":"message","time":"2017-07-14T02:40:00Z"}`}, sender.Lines())
+}
+
+func TestReceiver_ServeConnAcknowledgesInvalidMessages(t *testing.T) {
+	sender := &testSender{}
+	receiver := &Receiver{
+		Router: &forwarding.Router{Resolver: &testResolver{}, DefaultToken: "default-token"},
+		Sender: sender,
+	}
+	client, server := net.Pipe()
+	defer client.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go receiver.serveConn(ctx, server)
+
+	go client.Write(encodeTestMsgpack(t,
+		[]interface{}{"app.web", int64(1500000000), "not a record", map[string]interface{}{"chunk": "invalid"}},
+		[]interface{}{"app.web", int64(1500000000), map[string]interface{}{"log": "message"}, map[string]interface{}{"chunk": "valid"}},
+	))
+	reader := bufio.NewReader(client)
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for _, chunk := range []string{"invalid", "valid"} {
+		ack, err := DecodeMsgpack(reader)
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]interface{}{"ack": chunk}, ack)
+	}
+	assert.Equal(t, []string{`default-token {"log":"message","time":"2017-07-14T02:40:00Z"}`}, sender.Lines())
+}
@@ -0,0 +1,108 @@
+// Command insight-syslog-relay listens for syslog messages, RFC 5424 or RFC 3164, over udp and tcp and forwards them to
+// the insight logs named after their hostname or app-name. The logs are looked up in a single logset; messages from
+// sources matching no log are forwarded to a default log.
+//
+// The api key is read from the INSIGHT_API_KEY environment variable.
+//
+//	insight-syslog-relay -region eu -logset Appliances -default-log Unmatched -route 10.0.0.12=Firewall
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	insight "github.com/Tweddle-SE-Team/insight_goclient"
+	"github.com/Tweddle-SE-Team/insight_goclient/internal/forwarding"
+)
+
+func main() {
+	routes := forwarding.RoutesFlag{}
+	region := flag.String("region", os.Getenv(insight.REGION_ENV_VARIABLE), "insight region of the account")
+	logsetName := flag.String("logset", "", "logset holding the logs messages are forwarded to")
+	defaultLog := flag.String("default-log", "", "log of the logset receiving the messages of unmatched sources")
+	matchBy := flag.String("match", MATCH_BY_HOSTNAME, "message field matched against the log names: hostname or app-name")
+	udpAddress := flag.String("udp", ":514", "udp address to listen on, empty to disable")
+	tcpAddress := flag.String("tcp", ":514", "tcp address to listen on, empty to disable")
+	flag.Var(routes, "route", "source=log mapping overriding the log a source is forwarded to, may be repeated")
+	flag.Parse()
+
+	if *logsetName == "" || *defaultLog == "" {
+		log.Fatal("-logset and -default-log are mandatory")
+	}
+	if *matchBy != MATCH_BY_HOSTNAME && *matchBy != MATCH_BY_APP_NAME {
+		log.Fatalf("-match must be either %s or %s", MATCH_BY_HOSTNAME, MATCH_BY_APP_NAME)
+	}
+	if *udpAddress == "" && *tcpAddress == "" {
+		log.Fatal("At least one of -udp and -tcp is mandatory")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	client, err := insight.NewInsightClientWithOptions("", *region, insight.WithCredentials(&insight.EnvCredentials{}))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defaultToken, err := client.GetLogTokenContext(ctx, *logsetName, *defaultLog)
+	if err != nil {
+		log.Fatalf("Unable to get the token of the default log %s: %s", *defaultLog, err)
+	}
+	shipper, err := insight.NewShipper(*region, defaultToken)
+	if err != nil {
+		log.Fatal(err)
+	}
+	relay := &Relay{
+		Router: &forwarding.Router{
+			Resolver:     forwarding.NewInsightResolver(client, *logsetName),
+			DefaultToken: defaultToken,
+			Routes:       routes,
+		},
+		Sender:  shipper,
+		MatchBy: *matchBy,
+	}
+
+	var wg sync.WaitGroup
+	serve := func(name string, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				log.Printf("%s listener stopped: %s", name, err)
+				cancel()
+			}
+		}()
+	}
+	if *udpAddress != "" {
+		conn, err := net.ListenPacket("udp", *udpAddress)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Listening for syslog messages on udp %s", conn.LocalAddr())
+		serve("udp", func() error { return relay.ServeUDP(ctx, conn) })
+	}
+	if *tcpAddress != "" {
+		listener, err := net.Listen("tcp", *tcpAddress)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Listening for syslog messages on tcp %s", listener.Addr())
+		serve("tcp", func() error { return relay.ServeTCP(ctx, listener) })
+	}
+	wg.Wait()
+
+	if err := shipper.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tweddle-SE-Team/insight_goclient/internal/forwarding"
+)
+
+const (
+	UDP_MAX_DATAGRAM = 64 * 1024
+	// UDP_HANDLERS is the number of goroutines handling the datagrams received, so that looking up the log of a source
+	// does not hold up the reading of the next datagrams
+	UDP_HANDLERS = 8
+	// UDP_QUEUE_LENGTH bounds the datagrams waiting for a handler, the datagrams received beyond it are dropped
+	UDP_QUEUE_LENGTH   = 1024
+	TCP_READ_TIMEOUT   = 10 * time.Minute
+	MATCH_BY_HOSTNAME  = "hostname"
+	MATCH_BY_APP_NAME  = "app-name"
+	SOURCE_PEER_PREFIX = "peer:"
+)
+
+// Relay forwards syslog messages to the insight log named after their hostname or app-name, see forwarding.Router
+type Relay struct {
+	*forwarding.Router
+	Sender  forwarding.Sender
+	MatchBy string
+
+	now func() time.Time
+}
+
+// Handle parses a syslog message received from peer and forwards it to the log of its source. Messages which cannot be
+// parsed are forwarded as is to the default log.
+func (relay *Relay) Handle(ctx context.Context, frame []byte, peer string) error {
+	line := strings.TrimRight(string(frame), "\r\n\x00")
+	if line == "" {
+		return nil
+	}
+	token := relay.DefaultToken
+	message, err := ParseSyslog(frame, relay.clock())
+	if err != nil {
+		log.Printf("Forwarding unparsable message from %s to the default log: %s", peer, err)
+	} else {
+		token = relay.Token(ctx, relay.source(message, peer))
+	}
+	return relay.Sender.SendWithToken(token, line)
+}
+
+// source returns the name identifying the sender of the message, falling back to its peer address
+func (relay *Relay) source(message *SyslogMessage, peer string) string {
+	source := message.Hostname
+	if relay.MatchBy == MATCH_BY_APP_NAME {
+		source = message.AppName
+	}
+	if source == "" {
+		host, _, err := net.SplitHostPort(peer)
+		if err != nil {
+			host = peer
+		}
+		source = SOURCE_PEER_PREFIX + host
+	}
+	return source
+}
+
+func (relay *Relay) clock() time.Time {
+	if relay.now != nil {
+		return relay.now()
+	}
+	return time.Now()
+}
+
+type datagram struct {
+	frame []byte
+	peer  string
+}
+
+// ServeUDP relays the datagrams received by conn, one message each, until ctx is done. Datagrams are handled by
+// UDP_HANDLERS goroutines, the ones received while UDP_QUEUE_LENGTH datagrams are waiting for them are dropped.
+func (relay *Relay) ServeUDP(ctx context.Context, conn net.PacketConn) error {
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	datagrams := make(chan datagram, UDP_QUEUE_LENGTH)
+	var wg sync.WaitGroup
+	for i := 0; i < UDP_HANDLERS; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for received := range datagrams {
+				if err := relay.Handle(ctx, received.frame, received.peer); err != nil {
+					log.Printf("Unable to forward message from %s: %s", received.peer, err)
+				}
+			}
+		}()
+	}
+	defer wg.Wait()
+	defer close(datagrams)
+
+	buffer := make([]byte, UDP_MAX_DATAGRAM)
+	dropped := 0
+	for {
+		n, peer, err := conn.ReadFrom(buffer)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		select {
+		case datagrams <- datagram{append([]byte(nil), buffer[:n]...), peer.String()}:
+			if dropped > 0 {
+				log.Printf("Dropped %d datagrams received while the relay could not keep up", dropped)
+				dropped = 0
+			}
+		default:
+			dropped++
+		}
+	}
+}
+
+// ServeTCP relays the messages of the connections accepted by listener until ctx is done
+func (relay *Relay) ServeTCP(ctx context.Context, listener net.Listener) error {
+	return forwarding.ServeTCP(ctx, listener, relay.serveConn)
+}
+
+func (relay *Relay) serveConn(ctx context.Context, conn net.Conn) {
+	peer := conn.RemoteAddr().String()
+	reader := bufio.NewReader(conn)
+	for {
+		conn.SetReadDeadline(time.Now().Add(TCP_READ_TIMEOUT))
+		frame, err := ReadFrame(reader)
+		if err != nil {
+			return
+		}
+		if err := relay.Handle(ctx, frame, peer); err != nil {
+			log.Printf("Unable to forward message from %s: %s", peer, err)
+		}
+	}
+}
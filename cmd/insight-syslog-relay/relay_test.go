@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tweddle-SE-Team/insight_goclient/internal/forwarding"
+)
+
+type testResolver struct {
+	tokens map[string]string
+	err    error
+}
+
+func (resolver *testResolver) LogNames(ctx context.Context) (map[string]bool, error) {
+	if resolver.err != nil {
+		return nil, resolver.err
+	}
+	names := map[string]bool{}
+	for name := range resolver.tokens {
+		names[name] = true
+	}
+	return names, nil
+}
+
+func (resolver *testResolver) LogToken(ctx context.Context, logName string) (string, error) {
+	return resolver.tokens[logName], nil
+}
+
+type testSender struct {
+	mutex sync.Mutex
+	lines []string
+	sent  chan string
+}
+
+func (sender *testSender) SendWithToken(token, line string) error {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+	sender.lines = append(sender.lines, token+" "+line)
+	if sender.sent != nil {
+		sender.sent <- token + " " + line
+	}
+	return nil
+}
+
+// blockingResolver holds up the token lookups of the logs it has a channel for until the channel is closed
+type blockingResolver struct {
+	testResolver
+	blocked map[string]chan struct{}
+}
+
+func (resolver *blockingResolver) LogToken(ctx context.Context, logName string) (string, error) {
+	if blocked, ok := resolver.blocked[logName]; ok {
+		<-blocked
+	}
+	return resolver.testResolver.LogToken(ctx, logName)
+}
+
+func TestRelay_Handle(t *testing.T) {
+	sender := &testSender{}
+	relay := &Relay{
+		Router: &forwarding.Router{
+			Resolver:     &testResolver{tokens: map[string]string{"firewall": "firewall-token", "Switches": "switches-token"}},
+			DefaultToken: "default-token",
+			Routes:       map[string]string{"switch-1": "Switches"},
+		},
+		Sender:  sender,
+		MatchBy: MATCH_BY_HOSTNAME,
+	}
+	ctx := context.Background()
+	assert.Nil(t, relay.Handle(ctx, []byte("<34>1 - firewall app - - - blocked\n"), "10.0.0.1:514"))
+	assert.Nil(t, relay.Handle(ctx, []byte("<34>Oct 11 22:14:15 switch-1 link: up"), "10.0.0.2:514"))
+	assert.Nil(t, relay.Handle(ctx, []byte("<34>Oct 11 22:14:15 unknown app: hello"), "10.0.0.3:514"))
+	assert.Nil(t, relay.Handle(ctx, []byte("not syslog"), "10.0.0.4:514"))
+	assert.Equal(t, []string{
+		"firewall-token <34>1 - firewall app - - - blocked",
+		"switches-token <34>Oct 11 22:14:15 switch-1 link: up",
+		"default-token <34>Oct 11 22:14:15 unknown app: hello",
+		"default-token not syslog",
+	}, sender.lines)
+}
+
+func TestRelay_MatchByAppNameFallsBackToPeer(t *testing.T) {
+	relay := &Relay{
+		Router: &forwarding.Router{
+			Resolver:     &testResolver{tokens: map[string]string{"nginx": "nginx-token", "peer:10.0.0.9": "peer-token"}},
+			DefaultToken: "default-token",
+		},
+		MatchBy: MATCH_BY_APP_NAME,
+	}
+	ctx := context.Background()
+	assert.Equal(t, "nginx-token", relay.Token(ctx, relay.source(&SyslogMessage{Hostname: "web-1", AppName: "nginx"}, "10.0.0.1:514")))
+	assert.Equal(t, "peer-token", relay.Token(ctx, relay.source(&SyslogMessage{}, "10.0.0.9:514")))
+}
+
+func TestRelay_ApiErrorsRouteToTheDefaultLog(t *testing.T) {
+	resolver := &testResolver{tokens: map[string]string{"firewall": "firewall-token"}, err: fmt.Errorf("api unreachable")}
+	sender := &testSender{}
+	relay := &Relay{
+		Router: &forwarding.Router{Resolver: resolver, DefaultToken: "default-token"},
+		Sender: sender,
+	}
+	ctx := context.Background()
+	assert.Nil(t, relay.Handle(ctx, []byte("<34>1 - firewall app - - - blocked"), "10.0.0.1:514"))
+	// The failure is cached for a while, the api is not called for every message during an outage
+	resolver.err = nil
+	assert.Nil(t, relay.Handle(ctx, []byte("<34>1 - firewall app - - - allowed"), "10.0.0.1:514"))
+	assert.Equal(t, []string{
+		"default-token <34>1 - firewall app - - - blocked",
+		"default-token <34>1 - firewall app - - - allowed",
+	}, sender.lines)
+}
+
+func TestRelay_ServeUDPDoesNotWaitForLookups(t *testing.T) {
+	release := make(chan struct{})
+	sender := &testSender{sent: make(chan string, 2)}
+	relay := &Relay{
+		Router: &forwarding.Router{
+			Resolver: &blockingResolver{
+				testResolver: testResolver{tokens: map[string]string{"slow": "slow-token", "fast": "fast-token"}},
+				blocked:      map[string]chan struct{}{"slow": release},
+			},
+			DefaultToken: "default-token",
+		},
+		Sender:  sender,
+		MatchBy: MATCH_BY_HOSTNAME,
+	}
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	go func() { served <- relay.ServeUDP(ctx, conn) }()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	assert.Nil(t, err)
+	defer client.Close()
+	client.Write([]byte("<34>1 - slow app - - - first"))
+	time.Sleep(10 * time.Millisecond)
+	client.Write([]byte("<34>1 - fast app - - - second"))
+
+	receive := func() string {
+		select {
+		case line := <-sender.sent:
+			return line
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected a message to be forwarded")
+			return ""
+		}
+	}
+	assert.Equal(t, "fast-token <34>1 - fast app - - - second", receive())
+	close(release)
+	assert.Equal(t, "slow-token <34>1 - slow app - - - first", receive())
+	cancel()
+	assert.Nil(t, <-served)
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	NIL_VALUE = "-"
+	// MAX_MESSAGE_BYTES bounds the size of a single syslog message read from a tcp stream
+	MAX_MESSAGE_BYTES = 64 * 1024
+	// MAX_FRAME_LENGTH_DIGITS bounds the length field of an octet counting frame
+	MAX_FRAME_LENGTH_DIGITS = 10
+	RFC3164_TIMESTAMP       = time.Stamp
+)
+
+// SyslogMessage holds the fields of a syslog message, either RFC 5424 or RFC 3164 (BSD) formatted. Fields missing
+// from the message are left empty.
+type SyslogMessage struct {
+	Facility       int
+	Severity       int
+	Version        int
+	Timestamp      time.Time
+	Hostname       string
+	AppName        string
+	ProcId         string
+	MsgId          string
+	StructuredData string
+	Message        string
+}
+
+// ParseSyslog parses an RFC 5424 message, falling back to RFC 3164 when the version is missing. Legacy messages are
+// parsed leniently as appliances rarely follow RFC 3164 strictly; now provides the year they lack.
+func ParseSyslog(line []byte, now time.Time) (*SyslogMessage, error) {
+	line = bytes.TrimRight(line, "\r\n\x00")
+	message := &SyslogMessage{}
+	rest, err := message.parsePriority(string(line))
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) >= 2 && rest[0] >= '1' && rest[0] <= '9' && strings.IndexByte(rest, ' ') > 0 {
+		versionEnd := strings.IndexByte(rest, ' ')
+		if version, err := strconv.Atoi(rest[:versionEnd]); err == nil && versionEnd <= 2 {
+			message.Version = version
+			return message, message.parseRFC5424(rest[versionEnd+1:])
+		}
+	}
+	message.parseRFC3164(rest, now)
+	return message, nil
+}
+
+func (message *SyslogMessage) parsePriority(line string) (string, error) {
+	if !strings.HasPrefix(line, "<") {
+		return "", fmt.Errorf("Invalid syslog message, missing priority: %q", line)
+	}
+	end := strings.IndexByte(line, '>')
+	if end < 2 || end > 4 {
+		return "", fmt.Errorf("Invalid syslog message, malformed priority: %q", line)
+	}
+	priority, err := strconv.Atoi(line[1:end])
+	if err != nil || priority < 0 || priority > 191 {
+		return "", fmt.Errorf("Invalid syslog message, malformed priority: %q", line)
+	}
+	message.Facility = priority / 8
+	message.Severity = priority % 8
+	return line[end+1:], nil
+}
+
+// parseRFC5424 parses TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]
+func (message *SyslogMessage) parseRFC5424(rest string) error {
+	fields := make([]string, 5)
+	for i := range fields {
+		end := strings.IndexByte(rest, ' ')
+		if end < 0 {
+			return fmt.Errorf("Invalid RFC 5424 syslog message, missing header fields")
+		}
+		fields[i], rest = rest[:end], rest[end+1:]
+	}
+	if fields[0] != NIL_VALUE {
+		timestamp, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return fmt.Errorf("Invalid RFC 5424 syslog message, malformed timestamp %s", fields[0])
+		}
+		message.Timestamp = timestamp
+	}
+	message.Hostname = nilValue(fields[1])
+	message.AppName = nilValue(fields[2])
+	message.ProcId = nilValue(fields[3])
+	message.MsgId = nilValue(fields[4])
+
+	structuredData, rest, err := splitStructuredData(rest)
+	if err != nil {
+		return err
+	}
+	message.StructuredData = nilValue(structuredData)
+	// The message may start with a byte order mark when it is UTF-8 encoded
+	message.Message = strings.TrimPrefix(rest, "\ufeff")
+	return nil
+}
+
+// splitStructuredData splits the structured data, either the nil value or a list of [elements], from the message
+func splitStructuredData(rest string) (string, string, error) {
+	if strings.HasPrefix(rest, NIL_VALUE) {
+		return NIL_VALUE, strings.TrimPrefix(rest[1:], " "), nil
+	}
+	end := 0
+	for end < len(rest) && rest[end] == '[' {
+		escaped := false
+		closed := false
+		for end++; end < len(rest); end++ {
+			if escaped {
+				escaped = false
+			} else if rest[end] == '\\' {
+				escaped = true
+			} else if rest[end] == ']' {
+				closed = true
+				end++
+				break
+			}
+		}
+		if !closed {
+			return "", "", fmt.Errorf("Invalid RFC 5424 syslog message, unterminated structured data")
+		}
+	}
+	if end == 0 {
+		return "", "", fmt.Errorf("Invalid RFC 5424 syslog message, malformed structured data")
+	}
+	return rest[:end], strings.TrimPrefix(rest[end:], " "), nil
+}
+
+// parseRFC3164 parses [TIMESTAMP HOSTNAME] TAG[PID]: MSG. Messages without a valid timestamp are kept whole
+func (message *SyslogMessage) parseRFC3164(rest string, now time.Time) {
+	if len(rest) < len(RFC3164_TIMESTAMP)+1 {
+		message.Message = rest
+		return
+	}
+	// Days of the month lower than 10 are padded with a space, e.g: "Oct  9 22:14:15"
+	timestamp, err := time.ParseInLocation(RFC3164_TIMESTAMP, rest[:len(RFC3164_TIMESTAMP)], now.Location())
+	if err != nil {
+		message.Message = rest
+		return
+	}
+	message.Timestamp = timestamp.AddDate(now.Year(), 0, 0)
+	if message.Timestamp.After(now.AddDate(0, 1, 0)) {
+		// Sent last year, e.g: on december 31st and received on january 1st
+		message.Timestamp = message.Timestamp.AddDate(-1, 0, 0)
+	}
+	rest = strings.TrimPrefix(rest[len(RFC3164_TIMESTAMP):], " ")
+
+	// The hostname is omitted by some senders, in which case the tag directly follows the timestamp
+	if end := strings.IndexByte(rest, ' '); end > 0 && !strings.ContainsAny(rest[:end], ":[") {
+		message.Hostname, rest = rest[:end], rest[end+1:]
+	}
+	tagEnd := strings.IndexAny(rest, ":[ ")
+	if tagEnd <= 0 || tagEnd > 32 {
+		message.Message = rest
+		return
+	}
+	message.AppName = rest[:tagEnd]
+	rest = rest[tagEnd:]
+	if strings.HasPrefix(rest, "[") {
+		if end := strings.IndexByte(rest, ']'); end > 0 {
+			message.ProcId, rest = rest[1:end], rest[end+1:]
+		}
+	}
+	rest = strings.TrimPrefix(rest, ":")
+	message.Message = strings.TrimPrefix(rest, " ")
+}
+
+func nilValue(field string) string {
+	if field == NIL_VALUE {
+		return ""
+	}
+	return field
+}
+
+// ReadFrame reads the next syslog message from a tcp stream, framed either by octet counting (RFC 6587, e.g:
+// "42 <34>1 ...") or by a trailing newline
+func ReadFrame(reader *bufio.Reader) ([]byte, error) {
+	first, err := reader.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	if first[0] >= '1' && first[0] <= '9' {
+		lengthField, err := readFrameLength(reader)
+		if err != nil {
+			return nil, err
+		}
+		length, err := strconv.Atoi(lengthField)
+		if err != nil || length <= 0 || length > MAX_MESSAGE_BYTES {
+			return nil, fmt.Errorf("Invalid octet counting frame length %q", lengthField)
+		}
+		frame := make([]byte, length)
+		if _, err := io.ReadFull(reader, frame); err != nil {
+			return nil, err
+		}
+		return frame, nil
+	}
+	var frame []byte
+	for {
+		chunk, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			if err == io.EOF && len(frame) > 0 {
+				return frame, nil
+			}
+			return nil, err
+		}
+		if len(frame)+len(chunk) <= MAX_MESSAGE_BYTES {
+			frame = append(frame, chunk...)
+		}
+		if !isPrefix {
+			return frame, nil
+		}
+	}
+}
+
+// readFrameLength reads the digits of an octet counting frame length and the space following them, reading at most
+// MAX_FRAME_LENGTH_DIGITS digits so that a peer cannot make the relay buffer an endless length
+func readFrameLength(reader *bufio.Reader) (string, error) {
+	var digits []byte
+	for {
+		char, err := reader.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if char == ' ' {
+			return string(digits), nil
+		}
+		if char < '0' || char > '9' || len(digits) >= MAX_FRAME_LENGTH_DIGITS {
+			return "", fmt.Errorf("Invalid octet counting frame length %q", append(digits, char))
+		}
+		digits = append(digits, char)
+	}
+}
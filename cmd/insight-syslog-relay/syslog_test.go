@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+var syslogTestNow = time.Date(2019, 1, 2, 10, 0, 0, 0, time.UTC)
+
+func TestSyslog_ParseRFC5424(t *testing.T) {
+	message, err := ParseSyslog([]byte(`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Application"][meta x="a\]b"] An application event`), syslogTestNow)
+	assert.Nil(t, err)
+	assert.Equal(t, &SyslogMessage{
+		Facility:       20,
+		Severity:       5,
+		Version:        1,
+		Timestamp:      time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC),
+		Hostname:       "mymachine.example.com",
+		AppName:        "evntslog",
+		MsgId:          "ID47",
+		StructuredData: `[exampleSDID@32473 iut="3" eventSource="Application"][meta x="a\]b"]`,
+		Message:        "An application event",
+	}, message)
+}
+
+func TestSyslog_ParseRFC5424WithNilValues(t *testing.T) {
+	message, err := ParseSyslog([]byte("<34>1 - - su - - -\r\n"), syslogTestNow)
+	assert.Nil(t, err)
+	assert.Equal(t, &SyslogMessage{Facility: 4, Severity: 2, Version: 1, AppName: "su"}, message)
+
+	message, err = ParseSyslog([]byte("<34>1 - host app 12 - - \xef\xbb\xbfBOM message"), syslogTestNow)
+	assert.Nil(t, err)
+	assert.Equal(t, "BOM message", message.Message)
+	assert.Equal(t, "12", message.ProcId)
+
+	_, err = ParseSyslog([]byte("<34>1 not-a-timestamp host app - - -"), syslogTestNow)
+	assert.NotNil(t, err)
+	_, err = ParseSyslog([]byte("<34>1 - host app - - [unterminated"), syslogTestNow)
+	assert.NotNil(t, err)
+}
+
+func TestSyslog_ParseRFC3164(t *testing.T) {
+	message, err := ParseSyslog([]byte("<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8"), syslogTestNow)
+	assert.Nil(t, err)
+	assert.Equal(t, &SyslogMessage{
+		Facility:  4,
+		Severity:  2,
+		Timestamp: time.Date(2018, 10, 11, 22, 14, 15, 0, time.UTC),
+		Hostname:  "mymachine",
+		AppName:   "su",
+		ProcId:    "123",
+		Message:   "'su root' failed for lonvick on /dev/pts/8",
+	}, message)
+
+	message, err = ParseSyslog([]byte("<13>Jan  2 09:59:00 sshd: Accepted publickey"), syslogTestNow)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2019, 1, 2, 9, 59, 0, 0, time.UTC), message.Timestamp)
+	assert.Equal(t, "", message.Hostname)
+	assert.Equal(t, "sshd", message.AppName)
+	assert.Equal(t, "Accepted publickey", message.Message)
+
+	message, err = ParseSyslog([]byte("<13>free form appliance message"), syslogTestNow)
+	assert.Nil(t, err)
+	assert.Equal(t, "free form appliance message", message.Message)
+	assert.True(t, message.Timestamp.IsZero())
+}
+
+func TestSyslog_ParseErrorsOnInvalidPriority(t *testing.T) {
+	for _, line := range []string{"no priority", "<>1 - - - - - -", "<192>1 - - - - - -", "<abc>message"} {
+		_, err := ParseSyslog([]byte(line), syslogTestNow)
+		assert.NotNil(t, err, line)
+	}
+}
+
+func TestSyslog_ReadFrame(t *testing.T) {
+	reader := bufio.NewReader(strings.NewReader("11 <13>framed\n<13>newline\r\n<13>last"))
+	frame, err := ReadFrame(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, "<13>framed\n", string(frame))
+	frame, err = ReadFrame(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, "<13>newline", string(frame))
+	frame, err = ReadFrame(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, "<13>last", string(frame))
+	_, err = ReadFrame(reader)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestSyslog_ReadFrameRejectsInvalidLengths(t *testing.T) {
+	for _, stream := range []string{
+		strings.Repeat("1", 1024*1024),
+		"12345678901 <13>too long",
+		"99999999 <13>too large",
+		"12a <13>not a number",
+	} {
+		_, err := ReadFrame(bufio.NewReader(strings.NewReader(stream)))
+		assert.NotNil(t, err)
+		assert.NotEqual(t, io.EOF, err)
+	}
+}
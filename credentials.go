@@ -0,0 +1,133 @@
+package insight_goclient
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	API_KEY_ENV_VARIABLE = "INSIGHT_API_KEY"
+	REGION_ENV_VARIABLE  = "INSIGHT_REGION"
+)
+
+// CredentialsProvider supplies the api key sent along with every request. It is called once per request so that
+// rotated keys are picked up without rebuilding the client. Implementations must be safe for concurrent use.
+type CredentialsProvider interface {
+	ApiKey(ctx context.Context) (string, error)
+}
+
+// StaticCredentials always provides the same api key
+type StaticCredentials string
+
+func (c StaticCredentials) ApiKey(ctx context.Context) (string, error) {
+	if c == "" {
+		return "", fmt.Errorf("No api key configured")
+	}
+	return string(c), nil
+}
+
+// EnvCredentials reads the api key from the environment variable named Variable, INSIGHT_API_KEY when empty
+type EnvCredentials struct {
+	Variable string
+}
+
+func (c *EnvCredentials) ApiKey(ctx context.Context) (string, error) {
+	variable := c.Variable
+	if variable == "" {
+		variable = API_KEY_ENV_VARIABLE
+	}
+	apiKey := os.Getenv(variable)
+	if apiKey == "" {
+		return "", fmt.Errorf("Environment variable %s is not set", variable)
+	}
+	return apiKey, nil
+}
+
+// FileCredentials reads the api key from a file, re-reading it whenever the file changes on disk. Surrounding
+// whitespace is ignored.
+type FileCredentials struct {
+	Path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	apiKey  string
+}
+
+// NewFileCredentials creates a FileCredentials reading the api key from path
+func NewFileCredentials(path string) *FileCredentials {
+	return &FileCredentials{Path: path}
+}
+
+func (c *FileCredentials) ApiKey(ctx context.Context) (string, error) {
+	info, err := os.Stat(c.Path)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.apiKey != "" && info.ModTime().Equal(c.modTime) && info.Size() == c.size {
+		return c.apiKey, nil
+	}
+	content, err := ioutil.ReadFile(c.Path)
+	if err != nil {
+		return "", err
+	}
+	apiKey := strings.TrimSpace(string(content))
+	if apiKey == "" {
+		return "", fmt.Errorf("No api key found in file %s", c.Path)
+	}
+	c.apiKey = apiKey
+	c.modTime = info.ModTime()
+	c.size = info.Size()
+	return c.apiKey, nil
+}
+
+// ChainCredentials returns the api key of the first provider which succeeds
+type ChainCredentials []CredentialsProvider
+
+func (c ChainCredentials) ApiKey(ctx context.Context) (string, error) {
+	var errs []string
+	for _, provider := range c {
+		apiKey, err := provider.ApiKey(ctx)
+		if err == nil && apiKey != "" {
+			return apiKey, nil
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	return "", fmt.Errorf("No credentials provider returned an api key: [%s]", strings.Join(errs, "; "))
+}
+
+// WithCredentials makes the client resolve the api key through provider on every request. The apiKey argument of
+// NewInsightClientWithOptions may then be left empty.
+func WithCredentials(provider CredentialsProvider) ClientOption {
+	return func(options *clientOptions) error {
+		if provider == nil {
+			return fmt.Errorf("provider input parameter is mandatory")
+		}
+		options.credentials = provider
+		return nil
+	}
+}
+
+// NewInsightClientFromEnv creates a insight client using the INSIGHT_API_KEY and INSIGHT_REGION environment
+// variables. The api key is read from the environment on every request.
+func NewInsightClientFromEnv(options ...ClientOption) (*InsightClient, error) {
+	options = append([]ClientOption{WithCredentials(&EnvCredentials{})}, options...)
+	return NewInsightClientWithOptions("", os.Getenv(REGION_ENV_VARIABLE), options...)
+}
+
+// getApiKey resolves the api key to be sent along with a request
+func (client *InsightClient) getApiKey(ctx context.Context) (string, error) {
+	if client.Credentials == nil {
+		return client.ApiKey, nil
+	}
+	return client.Credentials.ApiKey(ctx)
+}
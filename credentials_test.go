@@ -0,0 +1,96 @@
+package insight_goclient
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCredentials_StaticCredentials(t *testing.T) {
+	apiKey, err := StaticCredentials("apiKey").ApiKey(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "apiKey", apiKey)
+	_, err = StaticCredentials("").ApiKey(context.Background())
+	assert.NotNil(t, err)
+}
+
+func TestCredentials_EnvCredentials(t *testing.T) {
+	os.Setenv("INSIGHT_TEST_API_KEY", "env-api-key")
+	defer os.Unsetenv("INSIGHT_TEST_API_KEY")
+	apiKey, err := (&EnvCredentials{Variable: "INSIGHT_TEST_API_KEY"}).ApiKey(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "env-api-key", apiKey)
+	_, err = (&EnvCredentials{Variable: "INSIGHT_TEST_MISSING_API_KEY"}).ApiKey(context.Background())
+	assert.NotNil(t, err)
+}
+
+func TestCredentials_FileCredentialsPicksUpRotatedKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "insight-credentials")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "api-key")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("first-key\n"), 0600))
+
+	provider := NewFileCredentials(path)
+	apiKey, err := provider.ApiKey(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "first-key", apiKey)
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("second-key\n"), 0600))
+	later := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(path, later, later))
+	apiKey, err = provider.ApiKey(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "second-key", apiKey)
+
+	assert.Nil(t, os.Remove(path))
+	_, err = provider.ApiKey(context.Background())
+	assert.NotNil(t, err)
+}
+
+func TestCredentials_ChainCredentials(t *testing.T) {
+	chain := ChainCredentials{&EnvCredentials{Variable: "INSIGHT_TEST_MISSING_API_KEY"}, StaticCredentials("fallback-key")}
+	apiKey, err := chain.ApiKey(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "fallback-key", apiKey)
+
+	_, err = ChainCredentials{StaticCredentials("")}.ApiKey(context.Background())
+	assert.NotNil(t, err)
+}
+
+func TestCredentials_ClientResolvesApiKeyPerRequest(t *testing.T) {
+	var receivedApiKeys []string
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedApiKeys = append(receivedApiKeys, r.Header.Get("x-api-key"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer httpServer.Close()
+
+	os.Setenv("INSIGHT_TEST_API_KEY", "first-key")
+	defer os.Unsetenv("INSIGHT_TEST_API_KEY")
+	c, err := NewInsightClientWithOptions("", "", WithBaseUrl(httpServer.URL), WithCredentials(&EnvCredentials{Variable: "INSIGHT_TEST_API_KEY"}))
+	assert.Nil(t, err)
+	assert.Nil(t, c.DeleteLog("log-uuid"))
+	os.Setenv("INSIGHT_TEST_API_KEY", "second-key")
+	assert.Nil(t, c.DeleteLog("log-uuid"))
+	assert.Equal(t, []string{"first-key", "second-key"}, receivedApiKeys)
+
+	os.Unsetenv("INSIGHT_TEST_API_KEY")
+	assert.NotNil(t, c.DeleteLog("log-uuid"))
+	assert.Len(t, receivedApiKeys, 2)
+}
+
+func TestCredentials_NewInsightClientFromEnv(t *testing.T) {
+	os.Setenv(REGION_ENV_VARIABLE, "us")
+	defer os.Unsetenv(REGION_ENV_VARIABLE)
+	c, err := NewInsightClientFromEnv()
+	assert.Nil(t, err)
+	assert.Equal(t, "https://us.rest.logs.insight.rapid7.com", c.InsightUrl)
+	assert.NotNil(t, c.Credentials)
+}
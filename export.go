@@ -0,0 +1,268 @@
+package insight_goclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	EXPORTS_PATH                 = "/exports"
+	DOWNLOAD_LINK_REL            = "Download"
+	EXPORT_STATUS_COMPLETED      = "completed"
+	EXPORT_STATUS_FAILED         = "failed"
+	EXPORT_DOWNLOAD_MAX_ATTEMPTS = 5
+	EXPORT_DOWNLOAD_MAX_BACKOFF  = 30 * time.Second
+)
+
+// The Exports resource allows you to export the raw data of your logs. The following operations are supported:
+// - Create a new Export of a Log or of every Log of a Log Set
+// - Get details of an existing Export
+// - Download the archive of a completed Export
+
+// Export represents the entity used to get an existing export job from the insight API
+type Export struct {
+	Id     string   `json:"id,omitempty"`
+	Status string   `json:"status,omitempty"`
+	Logs   []string `json:"logs"`
+	Leql   *Leql    `json:"leql"`
+	Size   int64    `json:"size,omitempty"`
+	Links  []*Link  `json:"links,omitempty"`
+}
+
+type ExportRequest struct {
+	Export *Export `json:"export"`
+}
+
+// IsDone reports whether the export job is over, successfully or not
+func (export *Export) IsDone() bool {
+	return strings.EqualFold(export.Status, EXPORT_STATUS_COMPLETED) || strings.EqualFold(export.Status, EXPORT_STATUS_FAILED)
+}
+
+// PostLogExport starts exporting the data of the Log logged between from and to
+func (client *InsightClient) PostLogExport(log *Log, from, to time.Time) (*Export, error) {
+	return client.PostLogExportContext(context.Background(), log, from, to)
+}
+
+// PostLogExportContext starts exporting the data of the Log logged between from and to using the provided context
+func (client *InsightClient) PostLogExportContext(ctx context.Context, log *Log, from, to time.Time) (*Export, error) {
+	if log == nil || log.Id == "" {
+		return nil, fmt.Errorf("log input parameter with a valid Id is mandatory")
+	}
+	return client.postExport(ctx, []string{log.Id}, from, to)
+}
+
+// PostLogsetExport starts exporting the data of every Log of the Log Set logged between from and to
+func (client *InsightClient) PostLogsetExport(logset *Logset, from, to time.Time) (*Export, error) {
+	return client.PostLogsetExportContext(context.Background(), logset, from, to)
+}
+
+// PostLogsetExportContext starts exporting the data of every Log of the Log Set logged between from and to using
+// the provided context
+func (client *InsightClient) PostLogsetExportContext(ctx context.Context, logset *Logset, from, to time.Time) (*Export, error) {
+	if logset == nil || len(logset.LogsInfo) == 0 {
+		return nil, fmt.Errorf("logset input parameter with at least one log is mandatory")
+	}
+	logIds := make([]string, 0, len(logset.LogsInfo))
+	for _, logInfo := range logset.LogsInfo {
+		logIds = append(logIds, logInfo.Id)
+	}
+	return client.postExport(ctx, logIds, from, to)
+}
+
+func (client *InsightClient) postExport(ctx context.Context, logIds []string, from, to time.Time) (*Export, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("A time range where from is before to is mandatory to export logs")
+	}
+	exportRequest := ExportRequest{&Export{
+		Logs: logIds,
+		Leql: &Leql{During: &During{From: toEpochMillis(from), To: toEpochMillis(to)}},
+	}}
+	resp, err := client.postWithContext(ctx, EXPORTS_PATH, exportRequest)
+	if err != nil {
+		return nil, err
+	}
+	var createdExport ExportRequest
+	if err := json.Unmarshal(resp, &createdExport); err != nil {
+		return nil, err
+	}
+	return createdExport.Export, nil
+}
+
+// GetExport gets a specific Export from an account
+func (client *InsightClient) GetExport(exportId string) (*Export, error) {
+	return client.GetExportContext(context.Background(), exportId)
+}
+
+// GetExportContext gets a specific Export from an account using the provided context
+func (client *InsightClient) GetExportContext(ctx context.Context, exportId string) (*Export, error) {
+	var exportRequest ExportRequest
+	endpoint, err := client.getExportEndpoint(exportId)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.getWithContext(ctx, endpoint, &exportRequest); err != nil {
+		return nil, err
+	}
+	return exportRequest.Export, nil
+}
+
+// WaitForExport polls the Export every QueryPollInterval until it is over
+func (client *InsightClient) WaitForExport(exportId string) (*Export, error) {
+	return client.WaitForExportContext(context.Background(), exportId)
+}
+
+// WaitForExportContext polls the Export every QueryPollInterval until it is over using the provided context. An
+// error is returned when the export failed.
+func (client *InsightClient) WaitForExportContext(ctx context.Context, exportId string) (*Export, error) {
+	for {
+		export, err := client.GetExportContext(ctx, exportId)
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(export.Status, EXPORT_STATUS_FAILED) {
+			return export, fmt.Errorf("Export %s failed", exportId)
+		}
+		if export.IsDone() {
+			return export, nil
+		}
+		if err := sleepContext(ctx, client.queryPollInterval()); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// DownloadExport streams the archive of a completed Export to w, returning the number of bytes written
+func (client *InsightClient) DownloadExport(export *Export, w io.Writer) (int64, error) {
+	return client.DownloadExportContext(context.Background(), export, w)
+}
+
+// DownloadExportContext streams the archive of a completed Export to w using the provided context, returning the
+// number of bytes written. Interrupted downloads are resumed from where they stopped using range requests; errors
+// returned by w are not retried. Note that the Timeout of the http client, see WithTimeout, bounds the whole download
+// of the archive: a deadline set on ctx is better suited to large exports.
+func (client *InsightClient) DownloadExportContext(ctx context.Context, export *Export, w io.Writer) (int64, error) {
+	endpoint, err := client.getExportDownloadEndpoint(export)
+	if err != nil {
+		return 0, err
+	}
+	var written int64
+	var lastErr error
+	for attempt := 1; attempt <= EXPORT_DOWNLOAD_MAX_ATTEMPTS; attempt++ {
+		if attempt > 1 {
+			if err := sleepContext(ctx, exponentialBackoff(client.queryPollInterval(), attempt-1, EXPORT_DOWNLOAD_MAX_BACKOFF)); err != nil {
+				return written, err
+			}
+		}
+		n, done, err := client.downloadExportFrom(ctx, endpoint, written, w)
+		written += n
+		if err == nil {
+			return written, nil
+		}
+		if done || ctx.Err() != nil {
+			return written, err
+		}
+		lastErr = err
+	}
+	return written, fmt.Errorf("Download of export %s failed after %d attempts: %s", export.Id, EXPORT_DOWNLOAD_MAX_ATTEMPTS, lastErr)
+}
+
+// downloadExportFrom downloads the export archive starting at offset. The returned flag tells whether the error is
+// final, i.e. resuming the download would not help.
+func (client *InsightClient) downloadExportFrom(ctx context.Context, endpoint string, offset int64, w io.Writer) (int64, bool, error) {
+	request, err := client.newDownloadRequest(ctx, endpoint)
+	if err != nil {
+		return 0, true, err
+	}
+	if offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	response, err := client.openStream(request, http.StatusOK, http.StatusPartialContent)
+	if err != nil {
+		if apiError, ok := err.(*APIError); ok {
+			// Client errors other than rate limiting will not be solved by resuming the download
+			final := apiError.StatusCode < http.StatusInternalServerError && apiError.StatusCode != http.StatusTooManyRequests
+			return 0, final, err
+		}
+		return 0, false, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusPartialContent {
+		if start, ok := contentRangeStart(response.Header.Get("Content-Range")); !ok || start != offset {
+			return 0, true, fmt.Errorf("Export download resumed at %q instead of offset %d", response.Header.Get("Content-Range"), offset)
+		}
+	} else if offset > 0 {
+		// The server ignored the range request, skip what has already been written
+		if _, err := io.CopyN(ioutil.Discard, response.Body, offset); err != nil {
+			return 0, false, err
+		}
+	}
+	writer := &downloadWriter{writer: w}
+	n, err := io.Copy(writer, response.Body)
+	if writer.err != nil {
+		return n, true, writer.err
+	}
+	return n, false, err
+}
+
+// downloadWriter keeps the error of the writer a download is copied to, which is final unlike the read errors
+type downloadWriter struct {
+	writer io.Writer
+	err    error
+}
+
+func (w *downloadWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	if err != nil {
+		w.err = err
+	}
+	return n, err
+}
+
+// contentRangeStart returns the first byte position of a "bytes first-last/length" Content-Range header
+func contentRangeStart(contentRange string) (int64, bool) {
+	var start, end int64
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/", &start, &end); err != nil {
+		return 0, false
+	}
+	return start, true
+}
+
+// newDownloadRequest builds the request of an export download endpoint, which is either a path of the insight api or
+// an absolute url, e.g: of the storage holding the archive
+func (client *InsightClient) newDownloadRequest(ctx context.Context, endpoint string) (*http.Request, error) {
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return client.newRequest(ctx, http.MethodGet, endpoint, nil)
+	}
+	request, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	return request.WithContext(ctx), nil
+}
+
+func (client *InsightClient) getExportDownloadEndpoint(export *Export) (string, error) {
+	if export == nil || export.Id == "" {
+		return "", fmt.Errorf("export input parameter with a valid Id is mandatory")
+	}
+	if !strings.EqualFold(export.Status, EXPORT_STATUS_COMPLETED) {
+		return "", fmt.Errorf("Export %s is not completed yet, current status is %s", export.Id, export.Status)
+	}
+	if link := (&page{Links: export.Links}).link(DOWNLOAD_LINK_REL); link != "" {
+		return link, nil
+	}
+	return fmt.Sprintf("%s/%s/download", EXPORTS_PATH, export.Id), nil
+}
+
+func (client *InsightClient) getExportEndpoint(exportId string) (string, error) {
+	if exportId == "" {
+		return "", fmt.Errorf("exportId input parameter is mandatory")
+	} else {
+		return fmt.Sprintf("%s/%s", EXPORTS_PATH, exportId), nil
+	}
+}
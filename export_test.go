@@ -0,0 +1,200 @@
+package insight_goclient
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestExports_PostLogExport(t *testing.T) {
+	log := &Log{Id: "log-uuid", Name: "MyLog"}
+	from := time.Unix(1, 0)
+	to := time.Unix(2, 0)
+	expectedRequest := ExportRequest{&Export{
+		Logs: []string{"log-uuid"},
+		Leql: &Leql{During: &During{From: 1000, To: 2000}},
+	}}
+	expectedExport := &Export{
+		Id:     "export-uuid",
+		Status: "pending",
+		Logs:   []string{"log-uuid"},
+		Leql:   &Leql{During: &During{From: 1000, To: 2000}},
+	}
+
+	requestMatcher := NewRequestMatcher(http.MethodPost, "/exports", expectedRequest, http.StatusCreated, ExportRequest{expectedExport})
+	client := getTestClient(requestMatcher)
+	export, err := client.PostLogExport(log, from, to)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedExport, export)
+
+	_, err = client.PostLogExport(log, to, from)
+	assert.NotNil(t, err)
+}
+
+func TestExports_PostLogsetExport(t *testing.T) {
+	logset := &Logset{Id: "log-set-uuid", LogsInfo: []*Info{{Id: "log-1"}, {Id: "log-2"}}}
+	expectedRequest := ExportRequest{&Export{
+		Logs: []string{"log-1", "log-2"},
+		Leql: &Leql{During: &During{From: 1000, To: 2000}},
+	}}
+	expectedExport := &Export{Id: "export-uuid", Logs: []string{"log-1", "log-2"}}
+
+	requestMatcher := NewRequestMatcher(http.MethodPost, "/exports", expectedRequest, http.StatusCreated, ExportRequest{expectedExport})
+	client := getTestClient(requestMatcher)
+	export, err := client.PostLogsetExport(logset, time.Unix(1, 0), time.Unix(2, 0))
+	assert.Nil(t, err)
+	assert.Equal(t, "export-uuid", export.Id)
+
+	_, err = client.PostLogsetExport(&Logset{}, time.Unix(1, 0), time.Unix(2, 0))
+	assert.NotNil(t, err)
+}
+
+func TestExports_GetExportErrorsIfExportIdIsEmpty(t *testing.T) {
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/exports/", nil, http.StatusOK, ExportRequest{&Export{}})
+	client := getTestClient(requestMatcher)
+	_, err := client.GetExport("")
+	assert.NotNil(t, err)
+	assert.Error(t, err, "exportId input parameter is mandatory")
+}
+
+func TestExports_WaitForExport(t *testing.T) {
+	polls := 0
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		status := "pending"
+		if polls == 3 {
+			status = EXPORT_STATUS_COMPLETED
+		}
+		fmt.Fprintf(w, `{"export":{"id":"export-uuid","status":"%s","logs":["log-uuid"]}}`, status)
+	}))
+	defer httpServer.Close()
+	c := &InsightClient{InsightUrl: httpServer.URL, ApiKey: "apikey", HttpClient: &http.Client{}, QueryPollInterval: time.Millisecond}
+
+	export, err := c.WaitForExport("export-uuid")
+	assert.Nil(t, err)
+	assert.Equal(t, EXPORT_STATUS_COMPLETED, export.Status)
+	assert.Equal(t, 3, polls)
+}
+
+func TestExports_DownloadExportResumesInterruptedDownloads(t *testing.T) {
+	archive := bytes.Repeat([]byte("0123456789"), 1000)
+	var ranges []string
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/exports/export-uuid/archive", r.URL.Path)
+		ranges = append(ranges, r.Header.Get("Range"))
+		if len(ranges) == 1 {
+			// Announce the whole archive but only send part of it, which makes the connection drop
+			w.Header().Set("Content-Length", strconv.Itoa(len(archive)))
+			w.Write(archive[:4000])
+			return
+		}
+		var offset int
+		fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &offset)
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(archive)-1, len(archive)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(archive[offset:])
+	}))
+	defer httpServer.Close()
+	c := &InsightClient{InsightUrl: httpServer.URL, ApiKey: "apikey", HttpClient: &http.Client{}, QueryPollInterval: time.Millisecond}
+
+	export := &Export{
+		Id:     "export-uuid",
+		Status: EXPORT_STATUS_COMPLETED,
+		Links:  []*Link{{Rel: "Download", Href: httpServer.URL + "/exports/export-uuid/archive"}},
+	}
+	var downloaded bytes.Buffer
+	written, err := c.DownloadExport(export, &downloaded)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(archive)), written)
+	assert.Equal(t, archive, downloaded.Bytes())
+	assert.Equal(t, []string{"", "bytes=4000-"}, ranges)
+}
+
+func TestExports_DownloadExportRequiresCompletedExport(t *testing.T) {
+	c := &InsightClient{InsightUrl: "http://localhost", ApiKey: "apikey", HttpClient: &http.Client{}}
+	_, err := c.DownloadExport(&Export{Id: "export-uuid", Status: "pending"}, &bytes.Buffer{})
+	assert.NotNil(t, err)
+}
+
+func TestExports_DownloadExportDoesNotResumeClientErrors(t *testing.T) {
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/exports/export-uuid/download", nil, http.StatusNotFound, nil)
+	client := getTestClient(requestMatcher)
+	_, err := client.DownloadExport(&Export{Id: "export-uuid", Status: EXPORT_STATUS_COMPLETED}, &bytes.Buffer{})
+	assert.True(t, IsNotFound(err))
+}
+
+func TestExports_DownloadExportFollowsLinksToOtherHosts(t *testing.T) {
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/bucket/archive.zip", r.URL.Path)
+		assert.Equal(t, "", r.Header.Get("x-api-key"))
+		w.Write([]byte("archive"))
+	}))
+	defer storage.Close()
+	c := &InsightClient{InsightUrl: "https://eu.rest.logs.insight.rapid7.com", ApiKey: "apikey", HttpClient: &http.Client{}}
+
+	export := &Export{
+		Id:     "export-uuid",
+		Status: EXPORT_STATUS_COMPLETED,
+		Links:  []*Link{{Rel: "Download", Href: storage.URL + "/bucket/archive.zip"}},
+	}
+	var downloaded bytes.Buffer
+	_, err := c.DownloadExport(export, &downloaded)
+	assert.Nil(t, err)
+	assert.Equal(t, "archive", downloaded.String())
+}
+
+type failingWriter struct {
+	writes int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, fmt.Errorf("disk full")
+}
+
+func TestExports_DownloadExportDoesNotRetryWriterErrors(t *testing.T) {
+	requests := 0
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("archive"))
+	}))
+	defer httpServer.Close()
+	c := &InsightClient{InsightUrl: httpServer.URL, ApiKey: "apikey", HttpClient: &http.Client{}, QueryPollInterval: time.Millisecond}
+
+	writer := &failingWriter{}
+	_, err := c.DownloadExport(&Export{Id: "export-uuid", Status: EXPORT_STATUS_COMPLETED}, writer)
+	assert.EqualError(t, err, "disk full")
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, 1, writer.writes)
+}
+
+func TestExports_DownloadExportChecksTheResumedRange(t *testing.T) {
+	archive := bytes.Repeat([]byte("0123456789"), 1000)
+	requests := 0
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Content-Length", strconv.Itoa(len(archive)))
+			w.Write(archive[:4000])
+			return
+		}
+		// Resume from the start of the archive instead of the requested offset
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", len(archive)-1, len(archive)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(archive)
+	}))
+	defer httpServer.Close()
+	c := &InsightClient{InsightUrl: httpServer.URL, ApiKey: "apikey", HttpClient: &http.Client{}, QueryPollInterval: time.Millisecond}
+
+	var downloaded bytes.Buffer
+	written, err := c.DownloadExport(&Export{Id: "export-uuid", Status: EXPORT_STATUS_COMPLETED}, &downloaded)
+	assert.NotNil(t, err)
+	assert.Equal(t, int64(4000), written)
+	assert.Equal(t, archive[:4000], downloaded.Bytes())
+	assert.Equal(t, 2, requests)
+}
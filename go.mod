@@ -1,3 +1,3 @@
 module github.com/Tweddle-SE-Team/insight_goclient
 
-go 1.12
+go 1.13
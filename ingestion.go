@@ -0,0 +1,269 @@
+package insight_goclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	INGESTION_API  = "https://%s.webhook.logs.insight.rapid7.com"
+	INGESTION_PATH = "/v1/noformat/%s"
+	// DEFAULT_INGESTION_MAX_BATCH_BYTES is the default limit of the uncompressed size of a batch of events
+	DEFAULT_INGESTION_MAX_BATCH_BYTES = 1024 * 1024
+)
+
+// IngestionClient sends log events to the http ingestion endpoint of a Log, identified by one of its tokens. Unlike
+// the InsightClient it does not need an api key: the token alone grants write access to the Log.
+type IngestionClient struct {
+	IngestionUrl string
+	Token        string
+	HttpClient   *http.Client
+	UserAgent    string
+	// Gzip compresses the request bodies
+	Gzip bool
+	// MaxBatchBytes is the maximum uncompressed size of a single request, DEFAULT_INGESTION_MAX_BATCH_BYTES when zero
+	MaxBatchBytes int
+	RetryPolicy   *RetryPolicy
+}
+
+// IngestionOption configures an IngestionClient created via NewIngestionClient
+type IngestionOption func(client *IngestionClient) error
+
+// WithIngestionBaseUrl overrides the ingestion url derived from the region
+func WithIngestionBaseUrl(baseUrl string) IngestionOption {
+	return func(client *IngestionClient) error {
+		parsedUrl, err := url.Parse(baseUrl)
+		if err != nil {
+			return fmt.Errorf("Invalid base url %s: %s", baseUrl, err)
+		}
+		if (parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https") || parsedUrl.Host == "" {
+			return fmt.Errorf("Invalid base url %s: an absolute http or https url is expected", baseUrl)
+		}
+		client.IngestionUrl = strings.TrimSuffix(baseUrl, "/")
+		return nil
+	}
+}
+
+// WithIngestionHttpClient makes the ingestion client use the given http client instead of a default one
+func WithIngestionHttpClient(httpClient *http.Client) IngestionOption {
+	return func(client *IngestionClient) error {
+		if httpClient == nil {
+			return fmt.Errorf("httpClient input parameter is mandatory")
+		}
+		client.HttpClient = httpClient
+		return nil
+	}
+}
+
+// WithGzip compresses the events sent by the ingestion client
+func WithGzip() IngestionOption {
+	return func(client *IngestionClient) error {
+		client.Gzip = true
+		return nil
+	}
+}
+
+// WithMaxBatchBytes limits the uncompressed size of every request sent by the ingestion client
+func WithMaxBatchBytes(maxBatchBytes int) IngestionOption {
+	return func(client *IngestionClient) error {
+		if maxBatchBytes <= 0 {
+			return fmt.Errorf("maxBatchBytes must be greater than zero, got %d", maxBatchBytes)
+		}
+		client.MaxBatchBytes = maxBatchBytes
+		return nil
+	}
+}
+
+// WithIngestionRetryPolicy sets the retry policy of the ingestion client; a nil policy disables retries
+func WithIngestionRetryPolicy(policy *RetryPolicy) IngestionOption {
+	return func(client *IngestionClient) error {
+		if policy != nil {
+			if err := policy.validate(); err != nil {
+				return err
+			}
+		}
+		client.RetryPolicy = policy
+		return nil
+	}
+}
+
+// NewIngestionClient creates a client sending log events to the Log owning token. Failed requests are retried,
+// including POSTs: delivering an event twice is preferred over losing it.
+func NewIngestionClient(region, token string, options ...IngestionOption) (*IngestionClient, error) {
+	if token == "" {
+		return nil, fmt.Errorf("token input parameter is mandatory")
+	}
+	retryPolicy := DefaultRetryPolicy()
+	retryPolicy.RetryableMethods = append(retryPolicy.RetryableMethods, http.MethodPost)
+	client := &IngestionClient{
+		Token:       token,
+		HttpClient:  &http.Client{},
+		RetryPolicy: retryPolicy,
+	}
+	for _, option := range options {
+		if err := option(client); err != nil {
+			return nil, err
+		}
+	}
+	if client.IngestionUrl == "" {
+		if err := ValidateRegion(region); err != nil {
+			return nil, err
+		}
+		client.IngestionUrl = fmt.Sprintf(INGESTION_API, region)
+	}
+	return client, nil
+}
+
+// Send sends a single plain text event
+func (client *IngestionClient) Send(message string) error {
+	return client.SendContext(context.Background(), message)
+}
+
+// SendContext sends a single plain text event using the provided context
+func (client *IngestionClient) SendContext(ctx context.Context, message string) error {
+	return client.SendBatchContext(ctx, []string{message})
+}
+
+// SendBatch sends plain text events, one per line, split in as many requests as MaxBatchBytes requires
+func (client *IngestionClient) SendBatch(messages []string) error {
+	return client.SendBatchContext(context.Background(), messages)
+}
+
+// SendBatchContext sends plain text events, one per line, split in as many requests as MaxBatchBytes requires using
+// the provided context. Messages spanning several lines are ingested as several events.
+func (client *IngestionClient) SendBatchContext(ctx context.Context, messages []string) error {
+	lines := make([][]byte, 0, len(messages))
+	for _, message := range messages {
+		lines = append(lines, []byte(message))
+	}
+	return client.sendLines(ctx, lines, "text/plain")
+}
+
+// SendJSON sends a single event encoded as JSON
+func (client *IngestionClient) SendJSON(event interface{}) error {
+	return client.SendJSONContext(context.Background(), event)
+}
+
+// SendJSONContext sends a single event encoded as JSON using the provided context
+func (client *IngestionClient) SendJSONContext(ctx context.Context, event interface{}) error {
+	return client.SendJSONBatchContext(ctx, []interface{}{event})
+}
+
+// SendJSONBatch sends events encoded as JSON, one per line, split in as many requests as MaxBatchBytes requires
+func (client *IngestionClient) SendJSONBatch(events []interface{}) error {
+	return client.SendJSONBatchContext(context.Background(), events)
+}
+
+// SendJSONBatchContext sends events encoded as JSON, one per line, split in as many requests as MaxBatchBytes
+// requires using the provided context
+func (client *IngestionClient) SendJSONBatchContext(ctx context.Context, events []interface{}) error {
+	lines := make([][]byte, 0, len(events))
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, line)
+	}
+	return client.sendLines(ctx, lines, "application/json")
+}
+
+// sendLines groups lines in newline separated batches no larger than MaxBatchBytes and posts them in order
+func (client *IngestionClient) sendLines(ctx context.Context, lines [][]byte, contentType string) error {
+	maxBatchBytes := client.maxBatchBytes()
+	for _, line := range lines {
+		if len(line) > maxBatchBytes {
+			return fmt.Errorf("Event of %d bytes exceeds the maximum batch size of %d bytes", len(line), maxBatchBytes)
+		}
+	}
+	var batch bytes.Buffer
+	for _, line := range lines {
+		if batch.Len() > 0 && batch.Len()+1+len(line) > maxBatchBytes {
+			if err := client.post(ctx, batch.Bytes(), contentType); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		if batch.Len() > 0 {
+			batch.WriteByte('\n')
+		}
+		batch.Write(line)
+	}
+	if batch.Len() == 0 {
+		return nil
+	}
+	return client.post(ctx, batch.Bytes(), contentType)
+}
+
+func (client *IngestionClient) post(ctx context.Context, payload []byte, contentType string) error {
+	if client.Gzip {
+		var compressed bytes.Buffer
+		writer := gzip.NewWriter(&compressed)
+		if _, err := writer.Write(payload); err != nil {
+			return err
+		}
+		if err := writer.Close(); err != nil {
+			return err
+		}
+		payload = compressed.Bytes()
+	}
+	request, err := http.NewRequest(http.MethodPost, client.IngestionUrl+fmt.Sprintf(INGESTION_PATH, client.Token), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request = request.WithContext(ctx)
+	request.Header.Set("Content-Type", contentType)
+	if client.Gzip {
+		request.Header.Set("Content-Encoding", "gzip")
+	}
+	if client.UserAgent != "" {
+		request.Header.Set("User-Agent", client.UserAgent)
+	}
+	_, _, err = sendWithRetries(client.RetryPolicy, request, func() (*http.Response, []byte, error) {
+		return client.roundTrip(request)
+	}, http.StatusOK, http.StatusCreated, http.StatusAccepted, http.StatusNoContent)
+	return client.redactToken(err)
+}
+
+// roundTrip sends the request once and returns the response along with its fully read body
+func (client *IngestionClient) roundTrip(request *http.Request) (*http.Response, []byte, error) {
+	response, err := client.HttpClient.Do(request)
+	if err != nil {
+		return nil, nil, contextError(request, err)
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, nil, contextError(request, err)
+	}
+	return response, body, nil
+}
+
+// redactToken removes the token, which is part of the url of the requests, from the errors returned to the callers
+// as they commonly end up in logs
+func (client *IngestionClient) redactToken(err error) error {
+	if client.Token == "" {
+		return err
+	}
+	switch typed := err.(type) {
+	case *url.Error:
+		typed.URL = strings.Replace(typed.URL, client.Token, REDACTED, -1)
+	case *APIError:
+		typed.Path = strings.Replace(typed.Path, client.Token, REDACTED, -1)
+	}
+	return err
+}
+
+func (client *IngestionClient) maxBatchBytes() int {
+	if client.MaxBatchBytes <= 0 {
+		return DEFAULT_INGESTION_MAX_BATCH_BYTES
+	}
+	return client.MaxBatchBytes
+}
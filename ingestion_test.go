@@ -0,0 +1,148 @@
+package insight_goclient
+
+import (
+	"compress/gzip"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type ingestedRequest struct {
+	path            string
+	contentType     string
+	contentEncoding string
+	body            string
+}
+
+func newIngestionTestServer(t *testing.T, statusCodes ...int) (*httptest.Server, func() []ingestedRequest) {
+	var mutex sync.Mutex
+	var requests []ingestedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reader := r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gzipReader, err := gzip.NewReader(r.Body)
+			assert.Nil(t, err)
+			reader = gzipReader
+		}
+		body, err := ioutil.ReadAll(reader)
+		assert.Nil(t, err)
+		mutex.Lock()
+		requests = append(requests, ingestedRequest{r.URL.Path, r.Header.Get("Content-Type"), r.Header.Get("Content-Encoding"), string(body)})
+		statusCode := http.StatusNoContent
+		if len(requests) <= len(statusCodes) {
+			statusCode = statusCodes[len(requests)-1]
+		}
+		mutex.Unlock()
+		w.WriteHeader(statusCode)
+	}))
+	return server, func() []ingestedRequest {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]ingestedRequest{}, requests...)
+	}
+}
+
+func TestIngestion_NewIngestionClient(t *testing.T) {
+	client, err := NewIngestionClient("eu", "log-token")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://eu.webhook.logs.insight.rapid7.com", client.IngestionUrl)
+
+	_, err = NewIngestionClient("eu", "")
+	assert.NotNil(t, err)
+	_, err = NewIngestionClient("mars", "log-token")
+	assert.NotNil(t, err)
+	_, err = NewIngestionClient("eu", "log-token", WithMaxBatchBytes(0))
+	assert.NotNil(t, err)
+}
+
+func TestIngestion_Send(t *testing.T) {
+	server, requests := newIngestionTestServer(t)
+	defer server.Close()
+	client, err := NewIngestionClient("", "log-token", WithIngestionBaseUrl(server.URL))
+	assert.Nil(t, err)
+
+	assert.Nil(t, client.Send("hello world"))
+	assert.Equal(t, []ingestedRequest{{"/v1/noformat/log-token", "text/plain", "", "hello world"}}, requests())
+}
+
+func TestIngestion_SendJSONWithGzip(t *testing.T) {
+	server, requests := newIngestionTestServer(t)
+	defer server.Close()
+	client, err := NewIngestionClient("", "log-token", WithIngestionBaseUrl(server.URL), WithGzip())
+	assert.Nil(t, err)
+
+	assert.Nil(t, client.SendJSONBatch([]interface{}{map[string]string{"level": "info"}, map[string]int{"count": 2}}))
+	assert.Equal(t, []ingestedRequest{{"/v1/noformat/log-token", "application/json", "gzip", "{\"level\":\"info\"}\n{\"count\":2}"}}, requests())
+}
+
+func TestIngestion_SendBatchSplitsOnMaxBatchBytes(t *testing.T) {
+	server, requests := newIngestionTestServer(t)
+	defer server.Close()
+	client, err := NewIngestionClient("", "log-token", WithIngestionBaseUrl(server.URL), WithMaxBatchBytes(10))
+	assert.Nil(t, err)
+
+	assert.Nil(t, client.SendBatch([]string{"aaaa", "bbbb", "cccc", "dddddddddd"}))
+	sent := requests()
+	assert.Len(t, sent, 3)
+	assert.Equal(t, "aaaa\nbbbb", sent[0].body)
+	assert.Equal(t, "cccc", sent[1].body)
+	assert.Equal(t, "dddddddddd", sent[2].body)
+
+	assert.NotNil(t, client.SendBatch([]string{"small", "way too large"}))
+	assert.Len(t, requests(), 3)
+}
+
+func TestIngestion_SendRetriesServerErrors(t *testing.T) {
+	server, requests := newIngestionTestServer(t, http.StatusServiceUnavailable, http.StatusOK)
+	defer server.Close()
+	client, err := NewIngestionClient("", "log-token", WithIngestionBaseUrl(server.URL),
+		WithIngestionRetryPolicy(&RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond, RetryableMethods: []string{http.MethodPost}}))
+	assert.Nil(t, err)
+
+	assert.Nil(t, client.Send("hello"))
+	sent := requests()
+	assert.Len(t, sent, 2)
+	assert.Equal(t, "hello", sent[1].body)
+}
+
+func TestIngestion_SendReturnsAPIError(t *testing.T) {
+	server, _ := newIngestionTestServer(t, http.StatusForbidden)
+	defer server.Close()
+	client, err := NewIngestionClient("", "log-token", WithIngestionBaseUrl(server.URL))
+	assert.Nil(t, err)
+
+	err = client.Send("hello")
+	assert.True(t, IsUnauthorized(err))
+}
+
+func TestIngestion_ErrorsDoNotHoldTheToken(t *testing.T) {
+	server, _ := newIngestionTestServer(t, http.StatusForbidden)
+	client, err := NewIngestionClient("", "secret-log-token", WithIngestionBaseUrl(server.URL), WithIngestionRetryPolicy(nil))
+	assert.Nil(t, err)
+
+	err = client.Send("hello")
+	assert.True(t, IsUnauthorized(err))
+	assert.Equal(t, "/v1/noformat/"+REDACTED, err.(*APIError).Path)
+
+	server.Close()
+	err = client.Send("hello")
+	assert.NotNil(t, err)
+	assert.NotContains(t, err.Error(), "secret-log-token")
+	assert.Contains(t, err.Error(), REDACTED)
+}
+
+func TestIngestion_SendReturnsContextErrors(t *testing.T) {
+	server, _ := newIngestionTestServer(t)
+	defer server.Close()
+	client, err := NewIngestionClient("", "log-token", WithIngestionBaseUrl(server.URL))
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, client.SendContext(ctx, "hello"))
+}
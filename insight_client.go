@@ -8,80 +8,187 @@ package insight_goclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 )
 
 const INSIGHT_API = "https://%s.rest.logs.insight.rapid7.com"
 
 type InsightClient struct {
-	InsightUrl string
-	ApiKey     string
-	HttpClient *http.Client
+	InsightUrl  string
+	ApiKey      string
+	HttpClient  *http.Client
+	UserAgent   string
+	RetryPolicy *RetryPolicy
+	RateLimiter *RateLimiter
+	Middlewares []Middleware
+	Logger      Logger
+	LogBodies   bool
+	Credentials CredentialsProvider
+	// QueryPollInterval is the wait between two polls of a query in progress or of a live tail,
+	// DEFAULT_QUERY_POLL_INTERVAL when zero
+	QueryPollInterval time.Duration
 }
 
 // NewInsightClient creates a insight client which exposes an interface with CRUD operations for each of the
 // resources provided by insight rest API
 func NewInsightClient(apiKey, region string) (*InsightClient, error) {
-	if apiKey == "" {
-		return nil, fmt.Errorf("ApiKey is mandatory to initialize Insight client")
-	}
-	if region == "" {
-		return nil, fmt.Errorf("Region is mandatory to initialize Insight client")
-	}
-	client := &http.Client{}
-	return &InsightClient{fmt.Sprintf(INSIGHT_API, region), apiKey, client}, nil
+	return NewInsightClientWithOptions(apiKey, region)
 }
 
 func (client *InsightClient) sendRequest(request *http.Request, expectedResponseCode int) ([]byte, error) {
+	_, body, err := client.sendRequestWithStatus(request, expectedResponseCode)
+	return body, err
+}
+
+// sendRequestWithStatus sends the request, accepting any of the expected response codes, and returns the response
+// status code along with its body
+func (client *InsightClient) sendRequestWithStatus(request *http.Request, expectedResponseCodes ...int) (int, []byte, error) {
 	if request.Body != nil {
-		requestBody, err := request.GetBody()
-		if err != nil {
-			return nil, err
-		}
-		requestBodyBuffer := new(bytes.Buffer)
-		requestBodyBuffer.ReadFrom(requestBody)
 		request.Header.Set("Content-Type", "application/json")
 	}
-	request.Header.Set("x-api-key", client.ApiKey)
-	response, err := client.HttpClient.Do(request)
+	response, body, err := sendWithRetries(client.RetryPolicy, request, func() (*http.Response, []byte, error) {
+		if err := client.prepareRequest(request); err != nil {
+			return nil, nil, err
+		}
+		return client.roundTrip(request)
+	}, expectedResponseCodes...)
 	if err != nil {
+		return 0, nil, err
+	}
+	return response.StatusCode, body, nil
+}
+
+// openStream sends the request once and returns the response without reading its body, which must be closed by the
+// caller. It is meant for large downloads which must not be buffered in memory.
+func (client *InsightClient) openStream(request *http.Request, expectedResponseCodes ...int) (*http.Response, error) {
+	if err := client.prepareRequest(request); err != nil {
 		return nil, err
 	}
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
+	start := time.Now()
+	response, err := client.send(request)
+	if client.Logger != nil {
+		client.logRequest(request, response, nil, time.Since(start), err)
+	}
 	if err != nil {
 		return nil, err
 	}
-	bodyString := string(body)
-	if response.StatusCode != expectedResponseCode {
-		return nil, fmt.Errorf("Received a non expected response status code %d, expected code was %d. Response: %s", response.StatusCode, expectedResponseCode, bodyString)
+	if !containsStatusCode(expectedResponseCodes, response.StatusCode) {
+		defer response.Body.Close()
+		body, _ := ioutil.ReadAll(response.Body)
+		return nil, newAPIError(request, response, expectedResponseCodes[0], body)
 	}
-	return body, nil
+	return response, nil
 }
 
-func (client *InsightClient) get(path string, resource interface{}) error {
-	url := client.getInsightUrl(path)
-	request, err := http.NewRequest(http.MethodGet, url, nil)
+// prepareRequest sets the api key and user agent of the request and waits for the rate limiter, before every attempt.
+// The api key is only sent to the insight api, not to the other hosts links may point to.
+func (client *InsightClient) prepareRequest(request *http.Request) error {
+	if client.isInsightUrl(request.URL) {
+		apiKey, err := client.getApiKey(request.Context())
+		if err != nil {
+			return err
+		}
+		request.Header.Set("x-api-key", apiKey)
+	}
+	if client.UserAgent != "" {
+		request.Header.Set("User-Agent", client.UserAgent)
+	}
+	if client.RateLimiter != nil {
+		return client.RateLimiter.Wait(request.Context())
+	}
+	return nil
+}
+
+func containsStatusCode(statusCodes []int, statusCode int) bool {
+	for _, code := range statusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// roundTrip sends the request once and returns the response along with its fully read body
+func (client *InsightClient) roundTrip(request *http.Request) (*http.Response, []byte, error) {
+	start := time.Now()
+	response, err := client.send(request)
+	var body []byte
+	if err == nil {
+		defer response.Body.Close()
+		if body, err = ioutil.ReadAll(response.Body); err != nil {
+			response, body, err = nil, nil, contextError(request, err)
+		}
+	}
+	if client.Logger != nil {
+		client.logRequest(request, response, body, time.Since(start), err)
+	}
+	return response, body, err
+}
+
+// send sends the request once through the middlewares and returns the response with its body unread
+func (client *InsightClient) send(request *http.Request) (*http.Response, error) {
+	response, err := client.handler()(request)
+	if err == nil && response == nil {
+		err = fmt.Errorf("No response returned for %s %s", request.Method, request.URL.Path)
+	}
+	if client.RateLimiter != nil {
+		client.RateLimiter.Observe(response)
+	}
 	if err != nil {
-		return err
+		if response != nil {
+			response.Body.Close()
+		}
+		return nil, contextError(request, err)
+	}
+	return response, nil
+}
+
+// contextError surfaces cancellations and deadlines as the context error itself so callers can compare against
+// context.Canceled and context.DeadlineExceeded
+func contextError(request *http.Request, err error) error {
+	if ctxErr := request.Context().Err(); ctxErr != nil {
+		return ctxErr
 	}
-	body, err := client.sendRequest(request, http.StatusOK)
+	return err
+}
+
+func (client *InsightClient) get(path string, resource interface{}) error {
+	return client.getWithContext(context.Background(), path, resource)
+}
+
+func (client *InsightClient) getWithContext(ctx context.Context, path string, resource interface{}) error {
+	body, err := client.getRawWithContext(ctx, path)
 	if err != nil {
 		return err
 	}
 	return json.Unmarshal(body, resource)
 }
 
+func (client *InsightClient) getRawWithContext(ctx context.Context, path string) ([]byte, error) {
+	request, err := client.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.sendRequest(request, http.StatusOK)
+}
+
 func (client *InsightClient) post(path string, requestBody interface{}) ([]byte, error) {
-	url := client.getInsightUrl(path)
+	return client.postWithContext(context.Background(), path, requestBody)
+}
+
+func (client *InsightClient) postWithContext(ctx context.Context, path string, requestBody interface{}) ([]byte, error) {
 	payload, err := json.Marshal(requestBody)
 	if err != nil {
 		return nil, err
 	}
-	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	request, err := client.newRequest(ctx, http.MethodPost, path, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -89,12 +196,15 @@ func (client *InsightClient) post(path string, requestBody interface{}) ([]byte,
 }
 
 func (client *InsightClient) put(path string, requestBody interface{}) ([]byte, error) {
-	url := client.getInsightUrl(path)
+	return client.putWithContext(context.Background(), path, requestBody)
+}
+
+func (client *InsightClient) putWithContext(ctx context.Context, path string, requestBody interface{}) ([]byte, error) {
 	payload, err := json.Marshal(requestBody)
 	if err != nil {
 		return nil, err
 	}
-	request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(payload))
+	request, err := client.newRequest(ctx, http.MethodPut, path, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -102,8 +212,11 @@ func (client *InsightClient) put(path string, requestBody interface{}) ([]byte,
 }
 
 func (client *InsightClient) delete(path string) error {
-	url := client.getInsightUrl(path)
-	request, err := http.NewRequest(http.MethodDelete, url, nil)
+	return client.deleteWithContext(context.Background(), path)
+}
+
+func (client *InsightClient) deleteWithContext(ctx context.Context, path string) error {
+	request, err := client.newRequest(ctx, http.MethodDelete, path, nil)
 	if err != nil {
 		return err
 	}
@@ -114,6 +227,31 @@ func (client *InsightClient) delete(path string) error {
 	return nil
 }
 
+// newRequest builds a request against the insight api bound to the given context
+func (client *InsightClient) newRequest(ctx context.Context, method, path string, payload []byte) (*http.Request, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context input parameter is mandatory")
+	}
+	requestUrl := client.getInsightUrl(path)
+	var request *http.Request
+	var err error
+	if payload != nil {
+		request, err = http.NewRequest(method, requestUrl, bytes.NewReader(payload))
+	} else {
+		request, err = http.NewRequest(method, requestUrl, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return request.WithContext(ctx), nil
+}
+
 func (client *InsightClient) getInsightUrl(path string) string {
 	return fmt.Sprintf("%s%s", client.InsightUrl, path)
 }
+
+// isInsightUrl tells whether target points to the host of the insight api
+func (client *InsightClient) isInsightUrl(target *url.URL) bool {
+	insightUrl, err := url.Parse(client.InsightUrl)
+	return err == nil && strings.EqualFold(insightUrl.Host, target.Host)
+}
@@ -1,11 +1,14 @@
 package insight_goclient
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func getTestClient(requestMatcher TestRequestMatcher) *InsightClient {
@@ -114,3 +117,37 @@ func TestInsightClient_ClientGetResponseNotStatusNoContent(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Contains(t, err.Error(), fmt.Sprintf("Received a non expected response status code %d", http.StatusUnauthorized))
 }
+
+func TestInsightClient_ClientGetContextCancelled(t *testing.T) {
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/api/testing", nil, http.StatusOK, &mockObject{})
+	c := getTestClient(requestMatcher)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := c.getWithContext(ctx, "/api/testing", &mockObject{})
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestInsightClient_ClientGetContextDeadlineExceeded(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer httpServer.Close()
+	c := &InsightClient{InsightUrl: httpServer.URL, ApiKey: "apikey", HttpClient: &http.Client{}}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := c.deleteWithContext(ctx, "/api/testing")
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestInsightClient_ClientGetContextMissing(t *testing.T) {
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/api/testing", nil, http.StatusOK, &mockObject{})
+	c := getTestClient(requestMatcher)
+	err := c.getWithContext(nil, "/api/testing", &mockObject{})
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "context input parameter is mandatory")
+}
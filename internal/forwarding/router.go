@@ -0,0 +1,203 @@
+// Package forwarding holds what the commands forwarding events received from other agents to the insight logs of a
+// logset have in common: routing the sources of the events to the logs, serving tcp connections and parsing the
+// -route flag.
+package forwarding
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	insight "github.com/Tweddle-SE-Team/insight_goclient"
+)
+
+const (
+	// ROUTE_CACHE_TTL is how long a source stays mapped to a log, or to the default log when no log matches it
+	ROUTE_CACHE_TTL = 5 * time.Minute
+	// ROUTE_FAILURE_TTL is how long a source stays mapped to the default log when its log could not be looked up, so
+	// that an api outage does not cost a lookup per event
+	ROUTE_FAILURE_TTL = 30 * time.Second
+	// MAX_CACHED_ROUTES bounds the routes cached by a Router, the least recently used being evicted beyond it as
+	// sources, e.g: syslog hostnames, can be made up by the senders
+	MAX_CACHED_ROUTES = 10000
+)
+
+// Sender is the part of the insight Shipper used to forward events
+type Sender interface {
+	SendWithToken(token, line string) error
+}
+
+// Resolver finds the logs of the logset and their tokens, see NewInsightResolver
+type Resolver interface {
+	LogNames(ctx context.Context) (map[string]bool, error)
+	LogToken(ctx context.Context, logName string) (string, error)
+}
+
+// Router maps the sources of events, e.g: hostnames or tags, to the token of the log named after them, falling back
+// to the default log. Routes are cached for ROUTE_CACHE_TTL, or ROUTE_FAILURE_TTL when the log could not be looked
+// up, up to MAX_CACHED_ROUTES of them.
+type Router struct {
+	Resolver     Resolver
+	DefaultToken string
+	// Routes maps sources to log names, taking precedence over logs named after the source
+	Routes map[string]string
+
+	mutex sync.Mutex
+	// routes indexes the elements of recent, which holds the cached routes from the most to the least recently used
+	routes map[string]*list.Element
+	recent *list.List
+	now    func() time.Time
+}
+
+type cachedRoute struct {
+	source  string
+	token   string
+	expires time.Time
+}
+
+// insightResolver resolves log names and tokens through the insight api. The log names of the logset are cached
+// for ROUTE_CACHE_TTL, failures to list them for ROUTE_FAILURE_TTL.
+type insightResolver struct {
+	client  *insight.InsightClient
+	logset  string
+	mutex   sync.Mutex
+	names   map[string]bool
+	err     error
+	expires time.Time
+}
+
+// NewInsightResolver creates a Resolver looking up the logs of the named logset through the insight api
+func NewInsightResolver(client *insight.InsightClient, logset string) Resolver {
+	return &insightResolver{client: client, logset: logset}
+}
+
+func (resolver *insightResolver) LogNames(ctx context.Context) (map[string]bool, error) {
+	resolver.mutex.Lock()
+	defer resolver.mutex.Unlock()
+	if (resolver.names != nil || resolver.err != nil) && time.Now().Before(resolver.expires) {
+		return resolver.names, resolver.err
+	}
+	logset, err := resolver.client.GetLogsetByNameContext(ctx, resolver.logset)
+	if err != nil {
+		resolver.names = nil
+		resolver.err = err
+		resolver.expires = time.Now().Add(ROUTE_FAILURE_TTL)
+		return nil, err
+	}
+	names := map[string]bool{}
+	for _, logInfo := range logset.LogsInfo {
+		names[logInfo.Name] = true
+	}
+	resolver.names = names
+	resolver.err = nil
+	resolver.expires = time.Now().Add(ROUTE_CACHE_TTL)
+	return names, nil
+}
+
+func (resolver *insightResolver) LogToken(ctx context.Context, logName string) (string, error) {
+	return resolver.client.GetLogTokenContext(ctx, resolver.logset, logName)
+}
+
+// Token returns the token of the log the source is routed to, the default one when no log matches it
+func (router *Router) Token(ctx context.Context, source string) string {
+	now := router.clock()
+	if token, ok := router.cached(source, now); ok {
+		return token
+	}
+	token, ttl := router.resolve(ctx, source)
+	router.cache(source, token, now, ttl)
+	return token
+}
+
+// resolve looks up the token of the log the source is routed to, returning how long it may be cached
+func (router *Router) resolve(ctx context.Context, source string) (string, time.Duration) {
+	logName := source
+	if routedName, ok := router.Routes[source]; ok {
+		logName = routedName
+	}
+	names, err := router.Resolver.LogNames(ctx)
+	if err != nil {
+		log.Printf("Unable to list the logs, routing %s to the default log: %s", source, err)
+		return router.DefaultToken, ROUTE_FAILURE_TTL
+	}
+	if !names[logName] {
+		return router.DefaultToken, ROUTE_CACHE_TTL
+	}
+	token, err := router.Resolver.LogToken(ctx, logName)
+	if err != nil {
+		log.Printf("Unable to get the token of log %s, routing %s to the default log: %s", logName, source, err)
+		return router.DefaultToken, ROUTE_FAILURE_TTL
+	}
+	return token, ROUTE_CACHE_TTL
+}
+
+func (router *Router) cached(source string, now time.Time) (string, bool) {
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+	element, ok := router.routes[source]
+	if !ok {
+		return "", false
+	}
+	route := element.Value.(*cachedRoute)
+	if !now.Before(route.expires) {
+		return "", false
+	}
+	router.recent.MoveToFront(element)
+	return route.token, true
+}
+
+// cache stores the route of source for ttl. The least recently used routes are evicted when they have expired, and
+// beyond MAX_CACHED_ROUTES in any case.
+func (router *Router) cache(source, token string, now time.Time, ttl time.Duration) {
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+	if router.routes == nil {
+		router.routes = map[string]*list.Element{}
+		router.recent = list.New()
+	}
+	route := &cachedRoute{source, token, now.Add(ttl)}
+	if element, ok := router.routes[source]; ok {
+		element.Value = route
+		router.recent.MoveToFront(element)
+	} else {
+		router.routes[source] = router.recent.PushFront(route)
+	}
+	for oldest := router.recent.Back(); oldest != nil; oldest = router.recent.Back() {
+		if router.recent.Len() <= MAX_CACHED_ROUTES && now.Before(oldest.Value.(*cachedRoute).expires) {
+			break
+		}
+		router.recent.Remove(oldest)
+		delete(router.routes, oldest.Value.(*cachedRoute).source)
+	}
+}
+
+func (router *Router) clock() time.Time {
+	if router.now != nil {
+		return router.now()
+	}
+	return time.Now()
+}
+
+// RoutesFlag collects the repeated -route source=log flags into Router.Routes
+type RoutesFlag map[string]string
+
+func (routes RoutesFlag) String() string {
+	var pairs []string
+	for source, logName := range routes {
+		pairs = append(pairs, source+"="+logName)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (routes RoutesFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("expected source=log, got %s", value)
+	}
+	routes[parts[0]] = parts[1]
+	return nil
+}
@@ -0,0 +1,122 @@
+package forwarding
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	insight "github.com/Tweddle-SE-Team/insight_goclient"
+)
+
+type testResolver struct {
+	tokens  map[string]string
+	err     error
+	lookups int
+}
+
+func (resolver *testResolver) LogNames(ctx context.Context) (map[string]bool, error) {
+	resolver.lookups++
+	if resolver.err != nil {
+		return nil, resolver.err
+	}
+	names := map[string]bool{}
+	for name := range resolver.tokens {
+		names[name] = true
+	}
+	return names, nil
+}
+
+func (resolver *testResolver) LogToken(ctx context.Context, logName string) (string, error) {
+	return resolver.tokens[logName], nil
+}
+
+func TestRouter_Token(t *testing.T) {
+	resolver := &testResolver{tokens: map[string]string{"web": "web-token", "Workers": "workers-token"}}
+	now := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+	router := &Router{
+		Resolver:     resolver,
+		DefaultToken: "default-token",
+		Routes:       map[string]string{"worker-1": "Workers"},
+		now:          func() time.Time { return now },
+	}
+	ctx := context.Background()
+	assert.Equal(t, "web-token", router.Token(ctx, "web"))
+	assert.Equal(t, "workers-token", router.Token(ctx, "worker-1"))
+	assert.Equal(t, "default-token", router.Token(ctx, "unknown"))
+	assert.Equal(t, 3, resolver.lookups)
+
+	resolver.tokens["unknown"] = "unknown-token"
+	assert.Equal(t, "default-token", router.Token(ctx, "unknown"))
+	now = now.Add(ROUTE_CACHE_TTL)
+	assert.Equal(t, "unknown-token", router.Token(ctx, "unknown"))
+}
+
+func TestRouter_ApiErrorsAreCachedBriefly(t *testing.T) {
+	resolver := &testResolver{tokens: map[string]string{"firewall": "firewall-token"}, err: fmt.Errorf("api unreachable")}
+	now := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+	router := &Router{Resolver: resolver, DefaultToken: "default-token", now: func() time.Time { return now }}
+	ctx := context.Background()
+	assert.Equal(t, "default-token", router.Token(ctx, "firewall"))
+	resolver.err = nil
+	assert.Equal(t, "default-token", router.Token(ctx, "firewall"))
+	assert.Equal(t, 1, resolver.lookups)
+	now = now.Add(ROUTE_FAILURE_TTL)
+	assert.Equal(t, "firewall-token", router.Token(ctx, "firewall"))
+	assert.Equal(t, 2, resolver.lookups)
+}
+
+func TestRouter_CacheIsBounded(t *testing.T) {
+	resolver := &testResolver{tokens: map[string]string{}}
+	now := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+	router := &Router{Resolver: resolver, DefaultToken: "default-token", now: func() time.Time { return now }}
+	ctx := context.Background()
+	for i := 0; i <= MAX_CACHED_ROUTES; i++ {
+		router.Token(ctx, fmt.Sprintf("spoofed-%d", i))
+	}
+	assert.Len(t, router.routes, MAX_CACHED_ROUTES)
+	assert.Equal(t, MAX_CACHED_ROUTES, router.recent.Len())
+
+	// The least recently used route was evicted, the most recent ones are still cached
+	router.Token(ctx, fmt.Sprintf("spoofed-%d", MAX_CACHED_ROUTES))
+	assert.Equal(t, MAX_CACHED_ROUTES+1, resolver.lookups)
+	router.Token(ctx, "spoofed-0")
+	assert.Equal(t, MAX_CACHED_ROUTES+2, resolver.lookups)
+
+	// Expired routes are swept
+	now = now.Add(ROUTE_CACHE_TTL)
+	router.Token(ctx, "fresh")
+	assert.Len(t, router.routes, 1)
+}
+
+func TestRouter_InsightResolverCachesFailures(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	client, err := insight.NewInsightClientWithOptions("api-key", "", insight.WithBaseUrl(server.URL),
+		insight.WithRetryPolicy(nil))
+	assert.Nil(t, err)
+	resolver := NewInsightResolver(client, "Appliances")
+
+	ctx := context.Background()
+	_, err = resolver.LogNames(ctx)
+	assert.NotNil(t, err)
+	_, err = resolver.LogNames(ctx)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, requests)
+}
+
+func TestRouter_RoutesFlag(t *testing.T) {
+	routes := RoutesFlag{}
+	assert.Nil(t, routes.Set("10.0.0.12=Firewall"))
+	assert.Nil(t, routes.Set("app.web=Web=1"))
+	assert.NotNil(t, routes.Set("Firewall"))
+	assert.NotNil(t, routes.Set("=Firewall"))
+	assert.Equal(t, RoutesFlag{"10.0.0.12": "Firewall", "app.web": "Web=1"}, routes)
+}
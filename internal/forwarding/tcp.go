@@ -0,0 +1,44 @@
+package forwarding
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// ServeTCP calls serve, in its own goroutine, for every connection accepted by listener until ctx is done. The
+// connection is closed once serve returns or as soon as ctx is done.
+func ServeTCP(ctx context.Context, listener net.Listener, serve func(ctx context.Context, conn net.Conn)) error {
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+		go serveConn(ctx, conn, serve)
+	}
+}
+
+func serveConn(ctx context.Context, conn net.Conn, serve func(ctx context.Context, conn net.Conn)) {
+	done := make(chan struct{})
+	defer close(done)
+	defer conn.Close()
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	serve(ctx, conn)
+}
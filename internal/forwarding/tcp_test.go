@@ -0,0 +1,39 @@
+package forwarding
+
+import (
+	"bufio"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestServeTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- ServeTCP(ctx, listener, func(ctx context.Context, conn net.Conn) {
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			conn.Write([]byte("echo " + line))
+			<-ctx.Done()
+		})
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	assert.Nil(t, err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	conn.Write([]byte("hello\n"))
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	assert.Nil(t, err)
+	assert.Equal(t, "echo hello\n", line)
+
+	cancel()
+	assert.Nil(t, <-served)
+	_, err = reader.ReadString('\n')
+	assert.NotNil(t, err)
+}
@@ -1,6 +1,7 @@
 package insight_goclient
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -32,21 +33,54 @@ type LabelRequest struct {
 
 // GetLabels gets details of a list of all Labels
 func (client *InsightClient) GetLabels() ([]*Label, error) {
-	var labels Labels
-	if err := client.get(LABELS_PATH, &labels); err != nil {
+	return client.GetLabelsContext(context.Background())
+}
+
+// GetLabelsContext gets details of a list of all Labels using the provided context
+func (client *InsightClient) GetLabelsContext(ctx context.Context) ([]*Label, error) {
+	var result []*Label
+	err := client.ForEachLabelContext(ctx, func(label *Label) error {
+		result = append(result, label)
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	return labels.Labels, nil
+	return result, nil
+}
+
+// ForEachLabel calls fn for every Label of the account, fetching them page by page
+func (client *InsightClient) ForEachLabel(fn func(label *Label) error) error {
+	return client.ForEachLabelContext(context.Background(), fn)
+}
+
+// ForEachLabelContext calls fn for every Label of the account, fetching them page by page using the provided
+// context. Iteration stops at the first error returned by fn; return ErrStopIteration to stop without failing
+func (client *InsightClient) ForEachLabelContext(ctx context.Context, fn func(label *Label) error) error {
+	var labels Labels
+	return client.getPages(ctx, LABELS_PATH, &labels, func() error {
+		for _, label := range labels.Labels {
+			if err := fn(label); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // GetLabel gets a specific Label from an account
 func (client *InsightClient) GetLabel(labelId string) (*Label, error) {
+	return client.GetLabelContext(context.Background(), labelId)
+}
+
+// GetLabelContext gets a specific Label from an account using the provided context
+func (client *InsightClient) GetLabelContext(ctx context.Context, labelId string) (*Label, error) {
 	var labelRequest LabelRequest
 	endpoint, err := client.getLabelEndpoint(labelId)
 	if err != nil {
 		return nil, err
 	}
-	if err := client.get(endpoint, &labelRequest); err != nil {
+	if err := client.getWithContext(ctx, endpoint, &labelRequest); err != nil {
 		return nil, err
 	}
 	return labelRequest.Label, nil
@@ -54,8 +88,13 @@ func (client *InsightClient) GetLabel(labelId string) (*Label, error) {
 
 // GetLabel gets a specific Label from an account by name
 func (client *InsightClient) GetLabelsByName(name, color string) ([]*Label, error) {
+	return client.GetLabelsByNameContext(context.Background(), name, color)
+}
+
+// GetLabelsByNameContext gets the Labels from an account matching name (and color if provided) using the provided context
+func (client *InsightClient) GetLabelsByNameContext(ctx context.Context, name, color string) ([]*Label, error) {
 	var result []*Label
-	labels, err := client.GetLabels()
+	labels, err := client.GetLabelsContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -72,8 +111,13 @@ func (client *InsightClient) GetLabelsByName(name, color string) ([]*Label, erro
 
 // PostTag creates a new Label
 func (client *InsightClient) PostLabel(label *Label) error {
+	return client.PostLabelContext(context.Background(), label)
+}
+
+// PostLabelContext creates a new Label using the provided context
+func (client *InsightClient) PostLabelContext(ctx context.Context, label *Label) error {
 	labelRequest := LabelRequest{label}
-	resp, err := client.post(LABELS_PATH, labelRequest)
+	resp, err := client.postWithContext(ctx, LABELS_PATH, labelRequest)
 	if err != nil {
 		return err
 	}
@@ -86,12 +130,17 @@ func (client *InsightClient) PostLabel(label *Label) error {
 
 // PutTag updates an existing Label
 func (client *InsightClient) PutLabel(label *Label) error {
+	return client.PutLabelContext(context.Background(), label)
+}
+
+// PutLabelContext updates an existing Label using the provided context
+func (client *InsightClient) PutLabelContext(ctx context.Context, label *Label) error {
 	labelRequest := LabelRequest{label}
 	endpoint, err := client.getLabelEndpoint(label.Id)
 	if err != nil {
 		return err
 	}
-	resp, err := client.put(endpoint, labelRequest)
+	resp, err := client.putWithContext(ctx, endpoint, labelRequest)
 	if err != nil {
 		return err
 	}
@@ -104,11 +153,16 @@ func (client *InsightClient) PutLabel(label *Label) error {
 
 // DeleteTag deletes a specific Label from an account.
 func (client *InsightClient) DeleteLabel(labelId string) error {
+	return client.DeleteLabelContext(context.Background(), labelId)
+}
+
+// DeleteLabelContext deletes a specific Label from an account using the provided context
+func (client *InsightClient) DeleteLabelContext(ctx context.Context, labelId string) error {
 	endpoint, err := client.getLabelEndpoint(labelId)
 	if err != nil {
 		return err
 	}
-	return client.delete(endpoint)
+	return client.deleteWithContext(ctx, endpoint)
 }
 
 func (client *InsightClient) getLabelEndpoint(labelId string) (string, error) {
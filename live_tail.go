@@ -0,0 +1,137 @@
+package insight_goclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	LIVE_LOGS_PATH = "/live/logs"
+	// LIVE_TAIL_DEDUPLICATION_WINDOW is the number of recent events remembered to drop overlapping batches
+	LIVE_TAIL_DEDUPLICATION_WINDOW = 10000
+	// LIVE_TAIL_MAX_FAILURES is the number of consecutive failures after which the live tail is restarted
+	LIVE_TAIL_MAX_FAILURES = 3
+	LIVE_TAIL_MAX_BACKOFF  = 30 * time.Second
+)
+
+// liveTail holds the state of a running live tail
+type liveTail struct {
+	client   *InsightClient
+	query    *Query
+	events   chan *Event
+	next     string
+	seen     map[string]bool
+	seenKeys []string
+}
+
+// LiveTail streams the events matching leqlFilter (which may be empty) as they are logged to the given logs. New
+// events are polled every QueryPollInterval and sent on the returned channel, which is closed once ctx is done.
+// Failures after the live tail has started are retried, restarting it from scratch when its continuation link keeps
+// failing; events delivered twice by overlapping batches are dropped.
+func (client *InsightClient) LiveTail(ctx context.Context, logIds []string, leqlFilter string) (<-chan *Event, error) {
+	if len(logIds) == 0 {
+		return nil, fmt.Errorf("At least one log id is mandatory to start a live tail")
+	}
+	query := &Query{Logs: logIds}
+	if leqlFilter != "" {
+		query.Leql = &Leql{Statement: leqlFilter}
+	}
+	tail := &liveTail{
+		client: client,
+		query:  query,
+		events: make(chan *Event),
+		seen:   map[string]bool{},
+	}
+	result, err := tail.start(ctx)
+	if err != nil {
+		return nil, err
+	}
+	go tail.run(ctx, result)
+	return tail.events, nil
+}
+
+// start submits the live tail request and returns its first batch of events
+func (tail *liveTail) start(ctx context.Context) (*QueryResult, error) {
+	payload, err := json.Marshal(tail.query)
+	if err != nil {
+		return nil, err
+	}
+	request, err := tail.client.newRequest(ctx, http.MethodPost, LIVE_LOGS_PATH, payload)
+	if err != nil {
+		return nil, err
+	}
+	statusCode, body, err := tail.client.sendRequestWithStatus(request, http.StatusOK, http.StatusAccepted)
+	if err != nil {
+		return nil, err
+	}
+	return tail.client.waitForQueryResult(ctx, statusCode, body)
+}
+
+func (tail *liveTail) run(ctx context.Context, result *QueryResult) {
+	defer close(tail.events)
+	failures := 0
+	for {
+		if result != nil {
+			if !tail.emit(ctx, result.Events) {
+				return
+			}
+			next, err := relativeEndpoint((&page{Links: result.Links}).nextLink())
+			if err == nil {
+				tail.next = next
+			}
+		}
+		wait := tail.client.queryPollInterval()
+		if failures > 0 {
+			wait = exponentialBackoff(wait, failures, LIVE_TAIL_MAX_BACKOFF)
+		}
+		if sleepContext(ctx, wait) != nil {
+			return
+		}
+		var err error
+		if tail.next == "" || failures >= LIVE_TAIL_MAX_FAILURES {
+			tail.next = ""
+			result, err = tail.start(ctx)
+		} else {
+			result, err = tail.client.getQueryResult(ctx, tail.next)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			result = nil
+			failures++
+			continue
+		}
+		failures = 0
+	}
+}
+
+// emit sends the events which have not been seen yet, returning false once the context is done
+func (tail *liveTail) emit(ctx context.Context, events []*Event) bool {
+	for _, event := range events {
+		key := fmt.Sprintf("%s/%d/%d", event.LogId, event.SequenceNumber, event.Timestamp)
+		if tail.seen[key] {
+			continue
+		}
+		tail.remember(key)
+		select {
+		case tail.events <- event:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// remember records key as seen, forgetting the oldest keys beyond the deduplication window
+func (tail *liveTail) remember(key string) {
+	tail.seen[key] = true
+	tail.seenKeys = append(tail.seenKeys, key)
+	if len(tail.seenKeys) > LIVE_TAIL_DEDUPLICATION_WINDOW {
+		delete(tail.seen, tail.seenKeys[0])
+		tail.seenKeys = tail.seenKeys[1:]
+	}
+}
@@ -0,0 +1,79 @@
+package insight_goclient
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLiveTail_StreamsDeduplicatedEvents(t *testing.T) {
+	var mu sync.Mutex
+	polls := 0
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method + " " + r.URL.Path {
+		case "POST /live/logs":
+			var query Query
+			json.NewDecoder(r.Body).Decode(&query)
+			assert.Equal(t, []string{"log-uuid"}, query.Logs)
+			assert.Equal(t, "where(error)", query.Leql.Statement)
+			w.Write([]byte(`{"events":[{"log_id":"log-uuid","sequence_number":1,"message":"one"},{"log_id":"log-uuid","sequence_number":2,"message":"two"}],"links":[{"rel":"Next","href":"https://eu.rest.logs.insight.rapid7.com/live/logs/continuation"}]}`))
+		case "GET /live/logs/continuation":
+			polls++
+			switch polls {
+			case 1:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			case 2:
+				w.Write([]byte(`{"events":[{"log_id":"log-uuid","sequence_number":2,"message":"two"},{"log_id":"log-uuid","sequence_number":3,"message":"three"}],"links":[{"rel":"Next","href":"/live/logs/continuation"}]}`))
+			default:
+				w.Write([]byte(`{"events":[],"links":[{"rel":"Next","href":"/live/logs/continuation"}]}`))
+			}
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.RequestURI())
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer httpServer.Close()
+	c := &InsightClient{InsightUrl: httpServer.URL, ApiKey: "apikey", HttpClient: &http.Client{}, QueryPollInterval: time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := c.LiveTail(ctx, []string{"log-uuid"}, "where(error)")
+	assert.Nil(t, err)
+
+	var messages []string
+	for len(messages) < 3 {
+		select {
+		case event := <-events:
+			messages = append(messages, event.Message)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for events, got %v", messages)
+		}
+	}
+	assert.Equal(t, []string{"one", "two", "three"}, messages)
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestLiveTail_ReturnsStartErrors(t *testing.T) {
+	requestMatcher := NewRequestMatcher(http.MethodPost, "/live/logs", &Query{Logs: []string{"log-uuid"}}, http.StatusForbidden, nil)
+	client := getTestClient(requestMatcher)
+	_, err := client.LiveTail(context.Background(), []string{"log-uuid"}, "")
+	assert.True(t, IsUnauthorized(err))
+
+	_, err = client.LiveTail(context.Background(), nil, "")
+	assert.NotNil(t, err)
+}
+
+func TestLiveTail_Backoff(t *testing.T) {
+	assert.Equal(t, 4*time.Second, exponentialBackoff(time.Second, 2, LIVE_TAIL_MAX_BACKOFF))
+	assert.Equal(t, LIVE_TAIL_MAX_BACKOFF, exponentialBackoff(time.Second, 10, LIVE_TAIL_MAX_BACKOFF))
+}
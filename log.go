@@ -1,6 +1,7 @@
 package insight_goclient
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -46,35 +47,73 @@ type LogRequest struct {
 
 // GetLogs lists all Logs for an account
 func (client *InsightClient) GetLogs() ([]*Log, error) {
-	var logs Logs
-	if err := client.get(LOGS_PATH, &logs); err != nil {
+	return client.GetLogsContext(context.Background())
+}
+
+// GetLogsContext lists all Logs for an account using the provided context
+func (client *InsightClient) GetLogsContext(ctx context.Context) ([]*Log, error) {
+	var result []*Log
+	err := client.ForEachLogContext(ctx, func(log *Log) error {
+		result = append(result, log)
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	return logs.Logs, nil
+	return result, nil
+}
+
+// ForEachLog calls fn for every Log of the account, fetching them page by page
+func (client *InsightClient) ForEachLog(fn func(log *Log) error) error {
+	return client.ForEachLogContext(context.Background(), fn)
+}
+
+// ForEachLogContext calls fn for every Log of the account, fetching them page by page using the provided
+// context. Iteration stops at the first error returned by fn; return ErrStopIteration to stop without failing
+func (client *InsightClient) ForEachLogContext(ctx context.Context, fn func(log *Log) error) error {
+	var logs Logs
+	return client.getPages(ctx, LOGS_PATH, &logs, func() error {
+		for _, log := range logs.Logs {
+			if err := fn(log); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // GetLog gets a specific Log from an account
 func (client *InsightClient) GetLog(logId string) (*Log, error) {
+	return client.GetLogContext(context.Background(), logId)
+}
+
+// GetLogContext gets a specific Log from an account using the provided context
+func (client *InsightClient) GetLogContext(ctx context.Context, logId string) (*Log, error) {
 	var logRequest LogRequest
 	endpoint, err := client.getLogEndpoint(logId)
 	if err != nil {
 		return nil, err
 	}
-	if err := client.get(endpoint, &logRequest); err != nil {
+	if err := client.getWithContext(ctx, endpoint, &logRequest); err != nil {
 		return nil, err
 	}
 	return logRequest.Log, nil
 }
 
 func (client *InsightClient) GetLogToken(logsetName, logName string) (string, error) {
-	logset, err := client.GetLogsetByName(logsetName)
+	return client.GetLogTokenContext(context.Background(), logsetName, logName)
+}
+
+// GetLogTokenContext gets the first token of a log within a logset using the provided context
+func (client *InsightClient) GetLogTokenContext(ctx context.Context, logsetName, logName string) (string, error) {
+	logset, err := client.GetLogsetByNameContext(ctx, logsetName)
 	if err != nil {
 		return "", err
 	}
 
 	for _, logInfo := range logset.LogsInfo {
 		if logInfo.Name == logName {
-			log, err := client.GetLog(logInfo.Id)
+			log, err := client.GetLogContext(ctx, logInfo.Id)
 			if err != nil {
 				return "", err
 			}
@@ -90,8 +129,13 @@ func (client *InsightClient) GetLogToken(logsetName, logName string) (string, er
 
 // PostTag creates a new Log
 func (client *InsightClient) PostLog(log *Log) error {
+	return client.PostLogContext(context.Background(), log)
+}
+
+// PostLogContext creates a new Log using the provided context
+func (client *InsightClient) PostLogContext(ctx context.Context, log *Log) error {
 	logRequest := LogRequest{log}
-	resp, err := client.post(LOGS_PATH, logRequest)
+	resp, err := client.postWithContext(ctx, LOGS_PATH, logRequest)
 	if err != nil {
 		return err
 	}
@@ -104,12 +148,17 @@ func (client *InsightClient) PostLog(log *Log) error {
 
 // PutTag updates an existing Log
 func (client *InsightClient) PutLog(log *Log) error {
+	return client.PutLogContext(context.Background(), log)
+}
+
+// PutLogContext updates an existing Log using the provided context
+func (client *InsightClient) PutLogContext(ctx context.Context, log *Log) error {
 	logRequest := LogRequest{log}
 	endpoint, err := client.getLogEndpoint(log.Id)
 	if err != nil {
 		return err
 	}
-	resp, err := client.put(endpoint, logRequest)
+	resp, err := client.putWithContext(ctx, endpoint, logRequest)
 	if err != nil {
 		return err
 	}
@@ -122,11 +171,16 @@ func (client *InsightClient) PutLog(log *Log) error {
 
 // DeleteTag deletes a specific Log from an account.
 func (client *InsightClient) DeleteLog(logId string) error {
+	return client.DeleteLogContext(context.Background(), logId)
+}
+
+// DeleteLogContext deletes a specific Log from an account using the provided context
+func (client *InsightClient) DeleteLogContext(ctx context.Context, logId string) error {
 	endpoint, err := client.getLogEndpoint(logId)
 	if err != nil {
 		return err
 	}
-	return client.delete(endpoint)
+	return client.deleteWithContext(ctx, endpoint)
 }
 
 func (client *InsightClient) getLogEndpoint(logId string) (string, error) {
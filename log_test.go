@@ -1,6 +1,7 @@
 package insight_goclient
 
 import (
+	"context"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"net/http"
@@ -200,3 +201,17 @@ func TestLogs_DeleteLogErrorsIfLogsetIdIsEmpty(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Error(t, err, "logId input parameter is mandatory")
 }
+
+func TestLogs_GetLogContext(t *testing.T) {
+	expectedLog := &Log{
+		Id:   "log-uuid",
+		Name: "MyLog",
+	}
+
+	url := fmt.Sprintf("/management/logs/%s", expectedLog.Id)
+	requestMatcher := NewRequestMatcher(http.MethodGet, url, nil, http.StatusOK, LogRequest{expectedLog})
+	client := getTestClient(requestMatcher)
+	returnedLog, err := client.GetLogContext(context.Background(), expectedLog.Id)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedLog, returnedLog)
+}
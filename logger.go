@@ -0,0 +1,135 @@
+package insight_goclient
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const REDACTED = "[REDACTED]"
+
+// tokensPattern matches the tokens array of a log, e.g: "tokens":["aaaa-bbbb"]
+var tokensPattern = regexp.MustCompile(`("tokens"\s*:\s*\[)([^\]]*)(\])`)
+
+// keyPattern matches the secret of an api key, e.g: "key":"aaaa-bbbb", which is returned when the key is created
+var keyPattern = regexp.MustCompile(`("key"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// quotedStringPattern matches every json string within a tokens array
+var quotedStringPattern = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+
+// Logger receives an entry for every round trip made by an InsightClient. The api key and log tokens are always
+// redacted from the entries.
+type Logger interface {
+	LogRequest(entry *RequestLogEntry)
+}
+
+// LoggerFunc adapts an ordinary function to the Logger interface
+type LoggerFunc func(entry *RequestLogEntry)
+
+func (f LoggerFunc) LogRequest(entry *RequestLogEntry) {
+	f(entry)
+}
+
+// RequestLogEntry describes a single round trip made to the insight api
+type RequestLogEntry struct {
+	Method         string
+	Url            string
+	RequestHeaders http.Header
+	StatusCode     int
+	Latency        time.Duration
+	// RequestBody and ResponseBody are only populated when bodies logging is enabled
+	RequestBody  string
+	ResponseBody string
+	Err          error
+}
+
+func (entry *RequestLogEntry) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s", entry.Method, entry.Url)
+	if entry.Err != nil {
+		fmt.Fprintf(&b, " error=%q", entry.Err.Error())
+	} else {
+		fmt.Fprintf(&b, " status=%d", entry.StatusCode)
+	}
+	fmt.Fprintf(&b, " latency=%s", entry.Latency)
+	if entry.RequestBody != "" {
+		fmt.Fprintf(&b, " request=%s", entry.RequestBody)
+	}
+	if entry.ResponseBody != "" {
+		fmt.Fprintf(&b, " response=%s", entry.ResponseBody)
+	}
+	return b.String()
+}
+
+// NewPrintfLogger creates a Logger writing one line per round trip through printf, e.g: log.Printf
+func NewPrintfLogger(printf func(format string, v ...interface{})) Logger {
+	return LoggerFunc(func(entry *RequestLogEntry) {
+		printf("insight: %s", entry)
+	})
+}
+
+// WithLogger makes the client log every round trip to logger, including request and response bodies when
+// logBodies is true
+func WithLogger(logger Logger, logBodies bool) ClientOption {
+	return func(options *clientOptions) error {
+		if logger == nil {
+			return fmt.Errorf("logger input parameter is mandatory")
+		}
+		options.logger = logger
+		options.logBodies = logBodies
+		return nil
+	}
+}
+
+// logRequest reports the round trip to the client logger, if any
+func (client *InsightClient) logRequest(request *http.Request, response *http.Response, body []byte, latency time.Duration, err error) {
+	apiKey := request.Header.Get("x-api-key")
+	entry := &RequestLogEntry{
+		Method:         request.Method,
+		Url:            request.URL.String(),
+		RequestHeaders: redactHeaders(request.Header),
+		Latency:        latency,
+		Err:            err,
+	}
+	if response != nil {
+		entry.StatusCode = response.StatusCode
+	}
+	if client.LogBodies {
+		if request.GetBody != nil {
+			if requestBody, err := request.GetBody(); err == nil {
+				payload, _ := ioutil.ReadAll(requestBody)
+				requestBody.Close()
+				entry.RequestBody = redactBody(string(payload), apiKey)
+			}
+		}
+		entry.ResponseBody = redactBody(string(body), apiKey)
+	}
+	client.Logger.LogRequest(entry)
+}
+
+func redactHeaders(headers http.Header) http.Header {
+	redacted := make(http.Header, len(headers))
+	for key, values := range headers {
+		if http.CanonicalHeaderKey(key) == http.CanonicalHeaderKey("x-api-key") {
+			redacted[key] = []string{REDACTED}
+			continue
+		}
+		redacted[key] = append([]string(nil), values...)
+	}
+	return redacted
+}
+
+// redactBody removes the api key, the secrets of api keys and the content of any tokens array found in the given body
+func redactBody(body, apiKey string) string {
+	if apiKey != "" {
+		body = strings.Replace(body, apiKey, REDACTED, -1)
+	}
+	body = keyPattern.ReplaceAllString(body, `${1}"`+REDACTED+`"`)
+	return tokensPattern.ReplaceAllStringFunc(body, func(tokens string) string {
+		groups := tokensPattern.FindStringSubmatch(tokens)
+		return groups[1] + quotedStringPattern.ReplaceAllString(groups[2], `"`+REDACTED+`"`) + groups[3]
+	})
+}
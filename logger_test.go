@@ -0,0 +1,77 @@
+package insight_goclient
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func getTestLoggingClient(requestMatcher TestRequestMatcher, logBodies bool) (*InsightClient, *[]*RequestLogEntry) {
+	var entries []*RequestLogEntry
+	client := getTestClient(requestMatcher)
+	client.Logger = LoggerFunc(func(entry *RequestLogEntry) {
+		entries = append(entries, entry)
+	})
+	client.LogBodies = logBodies
+	return client, &entries
+}
+
+func TestLogger_LogsRoundTripWithoutBodies(t *testing.T) {
+	requestMatcher := NewRequestMatcher(http.MethodDelete, "/management/logs/log-uuid", nil, http.StatusNoContent, nil)
+	client, entries := getTestLoggingClient(requestMatcher, false)
+	assert.Nil(t, client.DeleteLog("log-uuid"))
+
+	assert.Len(t, *entries, 1)
+	entry := (*entries)[0]
+	assert.Equal(t, http.MethodDelete, entry.Method)
+	assert.True(t, strings.HasSuffix(entry.Url, "/management/logs/log-uuid"))
+	assert.Equal(t, http.StatusNoContent, entry.StatusCode)
+	assert.Equal(t, REDACTED, entry.RequestHeaders.Get("x-api-key"))
+	assert.Equal(t, "apikey", client.ApiKey)
+	assert.Empty(t, entry.RequestBody)
+	assert.Empty(t, entry.ResponseBody)
+}
+
+func TestLogger_RedactsLogTokensFromBodies(t *testing.T) {
+	log := &Log{Name: "MyLog", Tokens: []string{"token-1", "token-2"}}
+	expectedLog := &Log{Id: "log-uuid", Name: "MyLog", Tokens: []string{"token-1", "token-2"}}
+	requestMatcher := NewRequestMatcher(http.MethodPost, "/management/logs", LogRequest{log}, http.StatusCreated, LogRequest{expectedLog})
+	client, entries := getTestLoggingClient(requestMatcher, true)
+	assert.Nil(t, client.PostLog(log))
+
+	entry := (*entries)[0]
+	assert.Contains(t, entry.RequestBody, `"tokens":["[REDACTED]","[REDACTED]"]`)
+	assert.Contains(t, entry.ResponseBody, `"tokens":["[REDACTED]","[REDACTED]"]`)
+	assert.NotContains(t, entry.RequestBody+entry.ResponseBody, "token-1")
+	assert.Equal(t, []string{"token-1", "token-2"}, log.Tokens)
+}
+
+func TestLogger_RedactsApiKeySecretsFromBodies(t *testing.T) {
+	apiKey := &ApiKey{Name: "rotation", Type: API_KEY_TYPE_READ_ONLY, Active: true}
+	expectedApiKey := &ApiKey{Id: "apikey-uuid", Name: "rotation", Type: API_KEY_TYPE_READ_ONLY, Active: true, Key: "new-secret"}
+	requestMatcher := NewRequestMatcher(http.MethodPost, "/management/apikeys", ApiKeyRequest{apiKey}, http.StatusCreated, ApiKeyRequest{expectedApiKey})
+	client, entries := getTestLoggingClient(requestMatcher, true)
+	assert.Nil(t, client.PostApiKey(apiKey))
+
+	entry := (*entries)[0]
+	assert.Contains(t, entry.ResponseBody, `"key":"[REDACTED]"`)
+	assert.NotContains(t, entry.ResponseBody, "new-secret")
+	assert.Equal(t, "new-secret", apiKey.Key)
+}
+
+func TestLogger_RedactBody(t *testing.T) {
+	assert.Equal(t, `{"key":"[REDACTED]","tokens": [ "[REDACTED]" ]}`, redactBody(`{"key":"secret","tokens": [ "abc" ]}`, "secret"))
+	assert.Equal(t, `{"tokens":[]}`, redactBody(`{"tokens":[]}`, ""))
+	assert.Equal(t, `{"apikey":{"key" : "[REDACTED]","name":"a\"key\""}}`, redactBody(`{"apikey":{"key" : "s\"ecret","name":"a\"key\""}}`, ""))
+}
+
+func TestLogger_PrintfLogger(t *testing.T) {
+	var line string
+	logger := NewPrintfLogger(func(format string, v ...interface{}) {
+		line = fmt.Sprintf(format, v...)
+	})
+	logger.LogRequest(&RequestLogEntry{Method: http.MethodGet, Url: "https://eu.rest.logs.insight.rapid7.com/management/logs", StatusCode: http.StatusOK})
+	assert.Equal(t, "insight: GET https://eu.rest.logs.insight.rapid7.com/management/logs status=200 latency=0s", line)
+}
@@ -1,6 +1,7 @@
 package insight_goclient
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -48,28 +49,66 @@ type LogsetRequest struct {
 
 // GetLogset gets details of a list of all Log Sets
 func (client *InsightClient) GetLogsets() ([]*Logset, error) {
-	var logsets Logsets
-	if err := client.get(LOGSETS_PATH, &logsets); err != nil {
+	return client.GetLogsetsContext(context.Background())
+}
+
+// GetLogsetsContext gets details of a list of all Log Sets using the provided context
+func (client *InsightClient) GetLogsetsContext(ctx context.Context) ([]*Logset, error) {
+	var result []*Logset
+	err := client.ForEachLogsetContext(ctx, func(logset *Logset) error {
+		result = append(result, logset)
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	return logsets.Logsets, nil
+	return result, nil
+}
+
+// ForEachLogset calls fn for every Logset of the account, fetching them page by page
+func (client *InsightClient) ForEachLogset(fn func(logset *Logset) error) error {
+	return client.ForEachLogsetContext(context.Background(), fn)
+}
+
+// ForEachLogsetContext calls fn for every Logset of the account, fetching them page by page using the provided
+// context. Iteration stops at the first error returned by fn; return ErrStopIteration to stop without failing
+func (client *InsightClient) ForEachLogsetContext(ctx context.Context, fn func(logset *Logset) error) error {
+	var logsets Logsets
+	return client.getPages(ctx, LOGSETS_PATH, &logsets, func() error {
+		for _, logset := range logsets.Logsets {
+			if err := fn(logset); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // GetLogsets gets details of an existing Log Set
 func (client *InsightClient) GetLogset(logsetId string) (*Logset, error) {
+	return client.GetLogsetContext(context.Background(), logsetId)
+}
+
+// GetLogsetContext gets details of an existing Log Set using the provided context
+func (client *InsightClient) GetLogsetContext(ctx context.Context, logsetId string) (*Logset, error) {
 	var logsetRequest LogsetRequest
 	endpoint, err := client.getLogsetEndpoint(logsetId)
 	if err != nil {
 		return nil, err
 	}
-	if err := client.get(endpoint, &logsetRequest); err != nil {
+	if err := client.getWithContext(ctx, endpoint, &logsetRequest); err != nil {
 		return nil, err
 	}
 	return logsetRequest.Logset, nil
 }
 
 func (client *InsightClient) GetLogsetByName(name string) (*Logset, error) {
-	logsets, err := client.GetLogsets()
+	return client.GetLogsetByNameContext(context.Background(), name)
+}
+
+// GetLogsetByNameContext gets details of an existing Log Set by name using the provided context
+func (client *InsightClient) GetLogsetByNameContext(ctx context.Context, name string) (*Logset, error) {
+	logsets, err := client.GetLogsetsContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -83,8 +122,13 @@ func (client *InsightClient) GetLogsetByName(name string) (*Logset, error) {
 
 // PostLogset creates a new LogSet
 func (client *InsightClient) PostLogset(logset *Logset) error {
+	return client.PostLogsetContext(context.Background(), logset)
+}
+
+// PostLogsetContext creates a new LogSet using the provided context
+func (client *InsightClient) PostLogsetContext(ctx context.Context, logset *Logset) error {
 	logsetRequest := LogsetRequest{logset}
-	resp, err := client.post(LOGSETS_PATH, logsetRequest)
+	resp, err := client.postWithContext(ctx, LOGSETS_PATH, logsetRequest)
 	if err != nil {
 		return err
 	}
@@ -97,12 +141,17 @@ func (client *InsightClient) PostLogset(logset *Logset) error {
 
 // PutTag updates an existing Logset
 func (client *InsightClient) PutLogset(logset *Logset) error {
+	return client.PutLogsetContext(context.Background(), logset)
+}
+
+// PutLogsetContext updates an existing Logset using the provided context
+func (client *InsightClient) PutLogsetContext(ctx context.Context, logset *Logset) error {
 	logsetRequest := LogsetRequest{logset}
 	endpoint, err := client.getLogsetEndpoint(logset.Id)
 	if err != nil {
 		return err
 	}
-	resp, err := client.put(endpoint, logsetRequest)
+	resp, err := client.putWithContext(ctx, endpoint, logsetRequest)
 	if err != nil {
 		return err
 	}
@@ -115,11 +164,16 @@ func (client *InsightClient) PutLogset(logset *Logset) error {
 
 // DeleteTag deletes a specific Logset from an account.
 func (client *InsightClient) DeleteLogset(logsetId string) error {
+	return client.DeleteLogsetContext(context.Background(), logsetId)
+}
+
+// DeleteLogsetContext deletes a specific Logset from an account using the provided context
+func (client *InsightClient) DeleteLogsetContext(ctx context.Context, logsetId string) error {
 	endpoint, err := client.getLogsetEndpoint(logsetId)
 	if err != nil {
 		return err
 	}
-	return client.delete(endpoint)
+	return client.deleteWithContext(ctx, endpoint)
 }
 
 // getLogEndpoint returns the rest end point to retrieve an individual log
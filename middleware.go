@@ -0,0 +1,72 @@
+package insight_goclient
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Handler sends a single request to the insight api and returns its response
+type Handler func(request *http.Request) (*http.Response, error)
+
+// Middleware wraps the round trip of every request sent by an InsightClient, e.g. to add authentication, logging,
+// metrics, headers or to inject faults. Middlewares are invoked once per attempt, so retried requests go through
+// the chain again.
+type Middleware func(next Handler) Handler
+
+// Observer is notified once a request has completed, successfully or not
+type Observer func(request *http.Request, response *http.Response, duration time.Duration, err error)
+
+// WithMiddleware appends the given middlewares to the client chain. The first middleware is the outermost one.
+func WithMiddleware(middlewares ...Middleware) ClientOption {
+	return func(options *clientOptions) error {
+		for _, middleware := range middlewares {
+			if middleware == nil {
+				return fmt.Errorf("middleware input parameter is mandatory")
+			}
+		}
+		options.middlewares = append(options.middlewares, middlewares...)
+		return nil
+	}
+}
+
+// Use appends the given middlewares to the client chain. It must not be called while requests are in flight.
+func (client *InsightClient) Use(middlewares ...Middleware) {
+	client.Middlewares = append(client.Middlewares, middlewares...)
+}
+
+// NewObserverMiddleware creates a middleware which reports every round trip along with its duration to observer
+func NewObserverMiddleware(observer Observer) Middleware {
+	return func(next Handler) Handler {
+		return func(request *http.Request) (*http.Response, error) {
+			start := time.Now()
+			response, err := next(request)
+			observer(request, response, time.Since(start), err)
+			return response, err
+		}
+	}
+}
+
+// NewHeaderMiddleware creates a middleware which sets the given headers on every request
+func NewHeaderMiddleware(headers http.Header) Middleware {
+	return func(next Handler) Handler {
+		return func(request *http.Request) (*http.Response, error) {
+			for key, values := range headers {
+				request.Header.Del(key)
+				for _, value := range values {
+					request.Header.Add(key, value)
+				}
+			}
+			return next(request)
+		}
+	}
+}
+
+// handler builds the handler chain wrapping the http client of the insight client
+func (client *InsightClient) handler() Handler {
+	handler := Handler(client.HttpClient.Do)
+	for i := len(client.Middlewares) - 1; i >= 0; i-- {
+		handler = client.Middlewares[i](handler)
+	}
+	return handler
+}
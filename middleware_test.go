@@ -0,0 +1,75 @@
+package insight_goclient
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMiddleware_ChainOrder(t *testing.T) {
+	requestMatcher := NewRequestMatcher(http.MethodDelete, "/management/logs/log-uuid", nil, http.StatusNoContent, nil)
+	client := getTestClient(requestMatcher)
+	var calls []string
+	tracing := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(request *http.Request) (*http.Response, error) {
+				calls = append(calls, name+" before")
+				response, err := next(request)
+				calls = append(calls, name+" after")
+				return response, err
+			}
+		}
+	}
+	client.Use(tracing("outer"), tracing("inner"))
+	assert.Nil(t, client.DeleteLog("log-uuid"))
+	assert.Equal(t, []string{"outer before", "inner before", "inner after", "outer after"}, calls)
+}
+
+func TestMiddleware_ObserverMiddleware(t *testing.T) {
+	requestMatcher := NewRequestMatcher(http.MethodDelete, "/management/logs/log-uuid", nil, http.StatusNoContent, nil)
+	client := getTestClient(requestMatcher)
+	var observedStatusCode int
+	var observedDuration time.Duration
+	client.Use(NewObserverMiddleware(func(request *http.Request, response *http.Response, duration time.Duration, err error) {
+		assert.Nil(t, err)
+		observedStatusCode = response.StatusCode
+		observedDuration = duration
+	}))
+	assert.Nil(t, client.DeleteLog("log-uuid"))
+	assert.Equal(t, http.StatusNoContent, observedStatusCode)
+	assert.True(t, observedDuration > 0)
+}
+
+func TestMiddleware_HeaderMiddleware(t *testing.T) {
+	requestMatcher := NewRequestMatcher(http.MethodDelete, "/management/logs/log-uuid", nil, http.StatusNoContent, nil)
+	client := getTestClient(requestMatcher)
+	var sentHeader string
+	client.Use(NewHeaderMiddleware(http.Header{"X-Team": []string{"platform"}}), NewObserverMiddleware(func(request *http.Request, response *http.Response, duration time.Duration, err error) {
+		sentHeader = request.Header.Get("X-Team")
+	}))
+	assert.Nil(t, client.DeleteLog("log-uuid"))
+	assert.Equal(t, "platform", sentHeader)
+}
+
+func TestMiddleware_FaultInjection(t *testing.T) {
+	requestMatcher := NewRequestMatcher(http.MethodDelete, "/management/logs/log-uuid", nil, http.StatusNoContent, nil)
+	client := getTestClient(requestMatcher)
+	client.Use(func(next Handler) Handler {
+		return func(request *http.Request) (*http.Response, error) {
+			return nil, fmt.Errorf("injected fault")
+		}
+	})
+	err := client.DeleteLog("log-uuid")
+	assert.NotNil(t, err)
+	assert.Equal(t, "injected fault", err.Error())
+}
+
+func TestMiddleware_WithMiddlewareOption(t *testing.T) {
+	_, err := NewInsightClientWithOptions("apiKey", "eu", WithMiddleware(nil))
+	assert.NotNil(t, err)
+	c, err := NewInsightClientWithOptions("apiKey", "eu", WithMiddleware(NewHeaderMiddleware(http.Header{})))
+	assert.Nil(t, err)
+	assert.Len(t, c.Middlewares, 1)
+}
@@ -0,0 +1,109 @@
+package insight_goclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	PAGE_SIZE       = 100
+	PAGE_SIZE_PARAM = "per_page"
+	NEXT_LINK_REL   = "Next"
+)
+
+// ErrStopIteration can be returned by the callback given to any of the ForEach methods to stop iterating without
+// failing
+var ErrStopIteration = errors.New("stop iteration")
+
+// page holds the links returned along with every page of a list endpoint
+type page struct {
+	Links []*Link `json:"links,omitempty"`
+}
+
+// nextLink returns the href of the Next link, if any
+func (p *page) nextLink() string {
+	return p.link(NEXT_LINK_REL)
+}
+
+// link returns the href of the first link with the given relation, if any
+func (p *page) link(rel string) string {
+	for _, link := range p.Links {
+		if strings.EqualFold(link.Rel, rel) {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// getPages fetches every page of the list endpoint at path, decoding each of them into resource before calling
+// visit. Pages are requested with the PAGE_SIZE page size and followed through their Next links.
+func (client *InsightClient) getPages(ctx context.Context, path string, resource interface{}, visit func() error) error {
+	endpoint, err := withPageSize(path)
+	if err != nil {
+		return err
+	}
+	visited := map[string]bool{}
+	for endpoint != "" {
+		if visited[endpoint] {
+			return fmt.Errorf("Pagination loop detected, page %s was already visited", endpoint)
+		}
+		visited[endpoint] = true
+		body, err := client.getRawWithContext(ctx, endpoint)
+		if err != nil {
+			return err
+		}
+		resourceValue := reflect.ValueOf(resource).Elem()
+		resourceValue.Set(reflect.Zero(resourceValue.Type()))
+		if err := json.Unmarshal(body, resource); err != nil {
+			return err
+		}
+		var links page
+		if err := json.Unmarshal(body, &links); err != nil {
+			return err
+		}
+		if err := visit(); err != nil {
+			if err == ErrStopIteration {
+				return nil
+			}
+			return err
+		}
+		endpoint, err = relativeEndpoint(links.nextLink())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withPageSize adds the page size parameter to path unless it is already present
+func withPageSize(path string) (string, error) {
+	endpoint, err := url.Parse(path)
+	if err != nil {
+		return "", err
+	}
+	query := endpoint.Query()
+	if query.Get(PAGE_SIZE_PARAM) == "" {
+		query.Set(PAGE_SIZE_PARAM, strconv.Itoa(PAGE_SIZE))
+	}
+	endpoint.RawQuery = query.Encode()
+	return endpoint.String(), nil
+}
+
+// relativeEndpoint strips the scheme and host of a link so that it is always sent to the client insight url, which
+// may point to a proxy, rather than to whichever host the api advertised
+func relativeEndpoint(link string) (string, error) {
+	if link == "" {
+		return "", nil
+	}
+	endpoint, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+	return endpoint.RequestURI(), nil
+}
@@ -0,0 +1,98 @@
+package insight_goclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// getPaginatedTestClient returns a client whose server serves the given pages of logs, linking each of them to the
+// next one through a Next link
+func getPaginatedTestClient(pages [][]*Log, requestedUrls *[]string) (*InsightClient, *httptest.Server) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requestedUrls = append(*requestedUrls, r.URL.RequestURI())
+		pageNumber := 0
+		fmt.Sscanf(r.URL.Query().Get("page"), "%d", &pageNumber)
+		response := map[string]interface{}{"logs": pages[pageNumber]}
+		if pageNumber+1 < len(pages) {
+			response["links"] = []*Link{
+				{
+					Rel:  "Next",
+					Href: fmt.Sprintf("https://eu.rest.logs.insight.rapid7.com/management/logs?page=%d&per_page=2", pageNumber+1),
+				},
+			}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	c := &InsightClient{InsightUrl: httpServer.URL, ApiKey: "apikey", HttpClient: &http.Client{}}
+	return c, httpServer
+}
+
+func TestPagination_GetLogsFollowsNextLinks(t *testing.T) {
+	pages := [][]*Log{
+		{{Id: "log-1", Name: "Log 1"}, {Id: "log-2", Name: "Log 2"}},
+		{{Id: "log-3", Name: "Log 3"}, {Id: "log-4", Name: "Log 4"}},
+		{{Id: "log-5", Name: "Log 5"}},
+	}
+	var requestedUrls []string
+	c, httpServer := getPaginatedTestClient(pages, &requestedUrls)
+	defer httpServer.Close()
+
+	logs, err := c.GetLogs()
+	assert.Nil(t, err)
+	assert.Len(t, logs, 5)
+	assert.Equal(t, "log-5", logs[4].Id)
+	assert.Equal(t, []string{
+		"/management/logs?per_page=100",
+		"/management/logs?page=1&per_page=2",
+		"/management/logs?page=2&per_page=2",
+	}, requestedUrls)
+}
+
+func TestPagination_ForEachLogStopsIteration(t *testing.T) {
+	pages := [][]*Log{
+		{{Id: "log-1"}, {Id: "log-2"}},
+		{{Id: "log-3"}, {Id: "log-4"}},
+	}
+	var requestedUrls []string
+	c, httpServer := getPaginatedTestClient(pages, &requestedUrls)
+	defer httpServer.Close()
+
+	var visited []string
+	err := c.ForEachLog(func(log *Log) error {
+		visited = append(visited, log.Id)
+		if len(visited) == 2 {
+			return ErrStopIteration
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"log-1", "log-2"}, visited)
+	assert.Len(t, requestedUrls, 1)
+}
+
+func TestPagination_ForEachLogPropagatesErrors(t *testing.T) {
+	pages := [][]*Log{{{Id: "log-1"}}}
+	var requestedUrls []string
+	c, httpServer := getPaginatedTestClient(pages, &requestedUrls)
+	defer httpServer.Close()
+
+	err := c.ForEachLog(func(log *Log) error {
+		return fmt.Errorf("failed to process %s", log.Id)
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, "failed to process log-1", err.Error())
+}
+
+func TestPagination_DetectsLoops(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tags":[],"links":[{"rel":"Next","href":"/management/tags?per_page=100"}]}`))
+	}))
+	defer httpServer.Close()
+	c := &InsightClient{InsightUrl: httpServer.URL, ApiKey: "apikey", HttpClient: &http.Client{}}
+	_, err := c.GetTags()
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,274 @@
+package insight_goclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	QUERY_LOGS_PATH             = "/query/logs"
+	SELF_LINK_REL               = "Self"
+	DEFAULT_QUERY_POLL_INTERVAL = time.Second
+)
+
+// The Log Search resource allows you to run LEQL queries against the data of your logs. The following operations
+// are supported:
+// - Query a list of Logs
+// - Query every Log of a Log Set
+
+// Query represents the entity used to submit a LEQL query to the insight API
+type Query struct {
+	Logs []string `json:"logs"`
+	Leql *Leql    `json:"leql"`
+}
+
+// Leql represents a LEQL statement along with the time range it applies to
+type Leql struct {
+	Statement string  `json:"statement,omitempty"`
+	During    *During `json:"during,omitempty"`
+}
+
+// During represents the time range of a query, either absolute (epoch milliseconds) or relative, e.g: "last 2 hours"
+type During struct {
+	From      int64  `json:"from,omitempty"`
+	To        int64  `json:"to,omitempty"`
+	TimeRange string `json:"time_range,omitempty"`
+}
+
+// QueryResult represents the results of a query once they are ready
+type QueryResult struct {
+	Logs       []string    `json:"logs,omitempty"`
+	Leql       *Leql       `json:"leql,omitempty"`
+	Statistics *Statistics `json:"statistics,omitempty"`
+	Events     []*Event    `json:"events,omitempty"`
+	Links      []*Link     `json:"links,omitempty"`
+}
+
+// Event represents a single log entry matched by a query
+type Event struct {
+	LogId          string   `json:"log_id"`
+	Timestamp      int64    `json:"timestamp"`
+	SequenceNumber int64    `json:"sequence_number"`
+	Message        string   `json:"message"`
+	Labels         []*Label `json:"labels,omitempty"`
+	Links          []*Link  `json:"links,omitempty"`
+	// LogName is the name of the log the event came from, only populated for queries run against a Log Set
+	LogName string `json:"-"`
+}
+
+// Statistics represents the results of a query using calculate or groupby functions
+type Statistics struct {
+	From        int64           `json:"from"`
+	To          int64           `json:"to"`
+	Type        string          `json:"type,omitempty"`
+	Count       int64           `json:"count,omitempty"`
+	Cardinality int64           `json:"cardinality,omitempty"`
+	Granularity int64           `json:"granularity,omitempty"`
+	Stats       json.RawMessage `json:"stats,omitempty"`
+	Groups      json.RawMessage `json:"groups,omitempty"`
+	Timeseries  json.RawMessage `json:"timeseries,omitempty"`
+}
+
+// Time returns the time at which the event was logged
+func (event *Event) Time() time.Time {
+	return time.Unix(0, event.Timestamp*int64(time.Millisecond))
+}
+
+// NewQuery creates a query running statement against the given logs between from and to
+func NewQuery(logIds []string, statement string, from, to time.Time) *Query {
+	return &Query{
+		Logs: logIds,
+		Leql: &Leql{
+			Statement: statement,
+			During: &During{
+				From: toEpochMillis(from),
+				To:   toEpochMillis(to),
+			},
+		},
+	}
+}
+
+// QueryLogs runs a LEQL statement against the given logs between from and to, waiting for the results to be ready
+func (client *InsightClient) QueryLogs(logIds []string, statement string, from, to time.Time) (*QueryResult, error) {
+	return client.QueryLogsContext(context.Background(), logIds, statement, from, to)
+}
+
+// QueryLogsContext runs a LEQL statement against the given logs between from and to, waiting for the results to be
+// ready using the provided context
+func (client *InsightClient) QueryLogsContext(ctx context.Context, logIds []string, statement string, from, to time.Time) (*QueryResult, error) {
+	return client.RunQueryContext(ctx, NewQuery(logIds, statement, from, to))
+}
+
+// RunQuery submits a query and waits for its results to be ready
+func (client *InsightClient) RunQuery(query *Query) (*QueryResult, error) {
+	return client.RunQueryContext(context.Background(), query)
+}
+
+// RunQueryContext submits a query and waits for its results to be ready using the provided context. Every page of
+// events is fetched and merged into the returned result.
+func (client *InsightClient) RunQueryContext(ctx context.Context, query *Query) (*QueryResult, error) {
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	request, err := client.newRequest(ctx, http.MethodPost, QUERY_LOGS_PATH, payload)
+	if err != nil {
+		return nil, err
+	}
+	statusCode, body, err := client.sendRequestWithStatus(request, http.StatusOK, http.StatusAccepted)
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.waitForQueryResult(ctx, statusCode, body)
+	if err != nil {
+		return nil, err
+	}
+	visited := map[string]bool{}
+	for {
+		next, err := relativeEndpoint((&page{Links: result.Links}).nextLink())
+		if err != nil {
+			return nil, err
+		}
+		if next == "" || visited[next] {
+			return result, nil
+		}
+		visited[next] = true
+		nextResult, err := client.getQueryResult(ctx, next)
+		if err != nil {
+			return nil, err
+		}
+		result.Events = append(result.Events, nextResult.Events...)
+		result.Links = nextResult.Links
+	}
+}
+
+// QueryLogset runs a LEQL statement against every log of the Log Set between from and to
+func (client *InsightClient) QueryLogset(logsetId, statement string, from, to time.Time) (*QueryResult, error) {
+	return client.QueryLogsetContext(context.Background(), logsetId, statement, from, to)
+}
+
+// QueryLogsetContext runs a LEQL statement against every log of the Log Set between from and to using the provided
+// context
+func (client *InsightClient) QueryLogsetContext(ctx context.Context, logsetId, statement string, from, to time.Time) (*QueryResult, error) {
+	logset, err := client.GetLogsetContext(ctx, logsetId)
+	if err != nil {
+		return nil, err
+	}
+	return client.queryLogset(ctx, logset, statement, from, to)
+}
+
+// QueryLogsetByName runs a LEQL statement against every log of the Log Set named name between from and to
+func (client *InsightClient) QueryLogsetByName(name, statement string, from, to time.Time) (*QueryResult, error) {
+	return client.QueryLogsetByNameContext(context.Background(), name, statement, from, to)
+}
+
+// QueryLogsetByNameContext runs a LEQL statement against every log of the Log Set named name between from and to
+// using the provided context
+func (client *InsightClient) QueryLogsetByNameContext(ctx context.Context, name, statement string, from, to time.Time) (*QueryResult, error) {
+	logset, err := client.GetLogsetByNameContext(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return client.queryLogset(ctx, logset, statement, from, to)
+}
+
+// queryLogset queries the logs listed in the Log Set info and tags every event with the name of its log
+func (client *InsightClient) queryLogset(ctx context.Context, logset *Logset, statement string, from, to time.Time) (*QueryResult, error) {
+	if len(logset.LogsInfo) == 0 {
+		return nil, fmt.Errorf("Logset %s does not contain any log", logset.Name)
+	}
+	logIds := make([]string, 0, len(logset.LogsInfo))
+	logNames := make(map[string]string, len(logset.LogsInfo))
+	for _, logInfo := range logset.LogsInfo {
+		logIds = append(logIds, logInfo.Id)
+		logNames[logInfo.Id] = logInfo.Name
+	}
+	result, err := client.QueryLogsContext(ctx, logIds, statement, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range result.Events {
+		event.LogName = logNames[event.LogId]
+	}
+	return result, nil
+}
+
+// getQueryResult fetches the query results at endpoint, waiting for them to be ready
+func (client *InsightClient) getQueryResult(ctx context.Context, endpoint string) (*QueryResult, error) {
+	request, err := client.newRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	statusCode, body, err := client.sendRequestWithStatus(request, http.StatusOK, http.StatusAccepted)
+	if err != nil {
+		return nil, err
+	}
+	return client.waitForQueryResult(ctx, statusCode, body)
+}
+
+// waitForQueryResult polls the Self link returned along with a 202 Accepted response until the results are ready
+func (client *InsightClient) waitForQueryResult(ctx context.Context, statusCode int, body []byte) (*QueryResult, error) {
+	for statusCode == http.StatusAccepted {
+		var progress page
+		if err := json.Unmarshal(body, &progress); err != nil {
+			return nil, err
+		}
+		self, err := relativeEndpoint(progress.link(SELF_LINK_REL))
+		if err != nil {
+			return nil, err
+		}
+		if self == "" {
+			return nil, fmt.Errorf("Query is still in progress but no %s link was returned", SELF_LINK_REL)
+		}
+		if err := sleepContext(ctx, client.queryPollInterval()); err != nil {
+			return nil, err
+		}
+		request, err := client.newRequest(ctx, http.MethodGet, self, nil)
+		if err != nil {
+			return nil, err
+		}
+		statusCode, body, err = client.sendRequestWithStatus(request, http.StatusOK, http.StatusAccepted)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var result QueryResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (client *InsightClient) queryPollInterval() time.Duration {
+	if client.QueryPollInterval > 0 {
+		return client.QueryPollInterval
+	}
+	return DEFAULT_QUERY_POLL_INTERVAL
+}
+
+func (query *Query) validate() error {
+	if query == nil {
+		return fmt.Errorf("query input parameter is mandatory")
+	}
+	if len(query.Logs) == 0 {
+		return fmt.Errorf("At least one log id is mandatory to run a query")
+	}
+	if query.Leql == nil || strings.TrimSpace(query.Leql.Statement) == "" {
+		return fmt.Errorf("A LEQL statement is mandatory to run a query")
+	}
+	if query.Leql.During == nil || (query.Leql.During.TimeRange == "" && query.Leql.During.From >= query.Leql.During.To) {
+		return fmt.Errorf("A time range where from is before to is mandatory to run a query")
+	}
+	return nil
+}
+
+func toEpochMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
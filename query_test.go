@@ -0,0 +1,141 @@
+package insight_goclient
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// getQueryTestClient returns a client whose server accepts a query, reports it in progress once and then serves
+// two pages of events
+func getQueryTestClient(t *testing.T, expectedQuery *Query) (*InsightClient, *httptest.Server) {
+	polls := 0
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.RequestURI() {
+		case "POST /query/logs":
+			body, _ := ioutil.ReadAll(r.Body)
+			expectedBody, _ := json.Marshal(expectedQuery)
+			assert.Equal(t, string(expectedBody), string(body))
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"id":"query-id","links":[{"rel":"Self","href":"https://eu.rest.logs.insight.rapid7.com/query/query-id"}]}`))
+		case "GET /query/query-id":
+			polls++
+			if polls == 1 {
+				w.WriteHeader(http.StatusAccepted)
+				w.Write([]byte(`{"id":"query-id","links":[{"rel":"Self","href":"https://eu.rest.logs.insight.rapid7.com/query/query-id"}]}`))
+				return
+			}
+			w.Write([]byte(`{"logs":["log-uuid"],"statistics":{"from":1000,"to":2000,"count":2},"events":[{"log_id":"log-uuid","timestamp":1500,"sequence_number":1,"message":"first"}],"links":[{"rel":"Next","href":"https://eu.rest.logs.insight.rapid7.com/query/query-id?page=2"}]}`))
+		case "GET /query/query-id?page=2":
+			w.Write([]byte(`{"logs":["log-uuid"],"events":[{"log_id":"log-uuid","timestamp":1600,"sequence_number":2,"message":"second"}]}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.RequestURI())
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	c := &InsightClient{InsightUrl: httpServer.URL, ApiKey: "apikey", HttpClient: &http.Client{}, QueryPollInterval: time.Millisecond}
+	return c, httpServer
+}
+
+func TestQuery_QueryLogs(t *testing.T) {
+	from := time.Unix(1, 0)
+	to := time.Unix(2, 0)
+	expectedQuery := &Query{
+		Logs: []string{"log-uuid"},
+		Leql: &Leql{Statement: "where(status=500)", During: &During{From: 1000, To: 2000}},
+	}
+	c, httpServer := getQueryTestClient(t, expectedQuery)
+	defer httpServer.Close()
+
+	result, err := c.QueryLogs([]string{"log-uuid"}, "where(status=500)", from, to)
+	assert.Nil(t, err)
+	assert.Len(t, result.Events, 2)
+	assert.Equal(t, "first", result.Events[0].Message)
+	assert.Equal(t, "second", result.Events[1].Message)
+	assert.Equal(t, int64(2), result.Events[1].SequenceNumber)
+	assert.Equal(t, time.Unix(1, 500*int64(time.Millisecond)), result.Events[0].Time())
+	assert.Equal(t, int64(2), result.Statistics.Count)
+}
+
+func TestQuery_QueryLogsValidatesInput(t *testing.T) {
+	c := &InsightClient{InsightUrl: "http://localhost", ApiKey: "apikey", HttpClient: &http.Client{}}
+	now := time.Now()
+	_, err := c.QueryLogs(nil, "where(status=500)", now.Add(-time.Hour), now)
+	assert.NotNil(t, err)
+	_, err = c.QueryLogs([]string{"log-uuid"}, " ", now.Add(-time.Hour), now)
+	assert.NotNil(t, err)
+	_, err = c.QueryLogs([]string{"log-uuid"}, "where(status=500)", now, now.Add(-time.Hour))
+	assert.NotNil(t, err)
+}
+
+func TestQuery_QueryLogsContextCancelledWhilePolling(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"id":"query-id","links":[{"rel":"Self","href":"/query/query-id"}]}`))
+	}))
+	defer httpServer.Close()
+	c := &InsightClient{InsightUrl: httpServer.URL, ApiKey: "apikey", HttpClient: &http.Client{}, QueryPollInterval: time.Millisecond}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.QueryLogsContext(ctx, []string{"log-uuid"}, "where(status=500)", time.Unix(1, 0), time.Unix(2, 0))
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+// getLogsetQueryTestClient returns a client whose server knows a single Log Set with two logs and answers queries
+// right away with one event per log
+func getLogsetQueryTestClient(t *testing.T) (*InsightClient, *httptest.Server) {
+	logset := &Logset{
+		Id:   "log-set-uuid",
+		Name: "production",
+		LogsInfo: []*Info{
+			{Id: "log-1", Name: "api"},
+			{Id: "log-2", Name: "worker"},
+		},
+	}
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /management/logsets/log-set-uuid":
+			json.NewEncoder(w).Encode(LogsetRequest{logset})
+		case "GET /management/logsets":
+			json.NewEncoder(w).Encode(Logsets{[]*Logset{logset}})
+		case "POST /query/logs":
+			var query Query
+			json.NewDecoder(r.Body).Decode(&query)
+			assert.Equal(t, []string{"log-1", "log-2"}, query.Logs)
+			w.Write([]byte(`{"events":[{"log_id":"log-1","message":"from api"},{"log_id":"log-2","message":"from worker"}]}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.RequestURI())
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	c := &InsightClient{InsightUrl: httpServer.URL, ApiKey: "apikey", HttpClient: &http.Client{}}
+	return c, httpServer
+}
+
+func TestQuery_QueryLogset(t *testing.T) {
+	c, httpServer := getLogsetQueryTestClient(t)
+	defer httpServer.Close()
+
+	result, err := c.QueryLogset("log-set-uuid", "where(error)", time.Unix(1, 0), time.Unix(2, 0))
+	assert.Nil(t, err)
+	assert.Len(t, result.Events, 2)
+	assert.Equal(t, "api", result.Events[0].LogName)
+	assert.Equal(t, "worker", result.Events[1].LogName)
+}
+
+func TestQuery_QueryLogsetByName(t *testing.T) {
+	c, httpServer := getLogsetQueryTestClient(t)
+	defer httpServer.Close()
+
+	result, err := c.QueryLogsetByName("production", "where(error)", time.Unix(1, 0), time.Unix(2, 0))
+	assert.Nil(t, err)
+	assert.Equal(t, "worker", result.Events[1].LogName)
+
+	_, err = c.QueryLogsetByName("staging", "where(error)", time.Unix(1, 0), time.Unix(2, 0))
+	assert.NotNil(t, err)
+}
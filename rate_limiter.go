@@ -0,0 +1,204 @@
+package insight_goclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	RATE_LIMIT_REMAINING_HEADER = "X-RateLimit-Remaining"
+	RATE_LIMIT_RESET_HEADER     = "X-RateLimit-Reset"
+	// RATE_LIMIT_MAX_PAUSE bounds how long a X-RateLimit-Reset header may pause requests
+	RATE_LIMIT_MAX_PAUSE = time.Hour
+	// RATE_LIMIT_RESET_EPOCH is the smallest X-RateLimit-Reset value read as a unix time rather than as a delay in seconds
+	RATE_LIMIT_RESET_EPOCH = 1000000000
+)
+
+// RateLimiter is a token bucket shared by every request sent by an InsightClient. Besides its own refill rate it
+// adapts to the rate limit headers returned by the insight api, pausing all requests until the api window resets
+// once no requests are remaining. A RateLimiter is safe for concurrent use.
+type RateLimiter struct {
+	mu          sync.Mutex
+	rate        float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+	waits       int
+	totalWait   time.Duration
+}
+
+// RateLimiterStats represents a snapshot of the state of a RateLimiter
+type RateLimiterStats struct {
+	// Tokens is the number of requests which can currently be sent without waiting
+	Tokens float64
+	// Waits is the number of requests which had to wait for a token
+	Waits int
+	// TotalWait is the accumulated time requests spent waiting for a token
+	TotalWait time.Duration
+	// PausedUntil is set when the api reported that no requests are remaining in the current window
+	PausedUntil time.Time
+}
+
+// NewRateLimiter creates a RateLimiter allowing requestsPerSecond requests on average with bursts of up to burst
+// requests
+func NewRateLimiter(requestsPerSecond float64, burst int) (*RateLimiter, error) {
+	if requestsPerSecond <= 0 {
+		return nil, fmt.Errorf("requestsPerSecond must be greater than zero, got %v", requestsPerSecond)
+	}
+	if burst < 1 {
+		return nil, fmt.Errorf("burst must be at least 1, got %d", burst)
+	}
+	return &RateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}, nil
+}
+
+// WithRateLimiter makes every request sent by the client go through the given rate limiter
+func WithRateLimiter(limiter *RateLimiter) ClientOption {
+	return func(options *clientOptions) error {
+		if limiter == nil {
+			return fmt.Errorf("limiter input parameter is mandatory")
+		}
+		options.rateLimiter = limiter
+		return nil
+	}
+}
+
+// Wait blocks until a request is allowed to be sent or the context is done
+func (limiter *RateLimiter) Wait(ctx context.Context) error {
+	waited := false
+	start := time.Now()
+	for {
+		wait := limiter.reserve()
+		if wait == 0 {
+			if waited {
+				limiter.recordWait(time.Since(start))
+			}
+			return nil
+		}
+		waited = true
+		if err := sleepContext(ctx, wait); err != nil {
+			limiter.recordWait(time.Since(start))
+			return err
+		}
+	}
+}
+
+// reserve takes a token if one is available, otherwise it returns how long to wait before trying again
+func (limiter *RateLimiter) reserve() time.Duration {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	now := time.Now()
+	limiter.refill(now)
+	if now.Before(limiter.pausedUntil) {
+		return limiter.pausedUntil.Sub(now)
+	}
+	if limiter.tokens >= 1 {
+		limiter.tokens--
+		return 0
+	}
+	return time.Duration((1 - limiter.tokens) / limiter.rate * float64(time.Second))
+}
+
+func (limiter *RateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(limiter.last)
+	if elapsed <= 0 {
+		return
+	}
+	limiter.tokens += elapsed.Seconds() * limiter.rate
+	if limiter.tokens > limiter.burst {
+		limiter.tokens = limiter.burst
+	}
+	limiter.last = now
+}
+
+func (limiter *RateLimiter) recordWait(wait time.Duration) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	limiter.waits++
+	limiter.totalWait += wait
+}
+
+// Observe adapts the limiter to the rate limit information returned by the insight api. When no requests are
+// remaining in the current window, or the api answered 429 with a Retry-After header, requests are paused until
+// the window resets.
+func (limiter *RateLimiter) Observe(response *http.Response) {
+	if response == nil {
+		return
+	}
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	now := time.Now()
+	limiter.refill(now)
+	if remaining, err := strconv.Atoi(response.Header.Get(RATE_LIMIT_REMAINING_HEADER)); err == nil && remaining >= 0 {
+		if float64(remaining) < limiter.tokens {
+			limiter.tokens = float64(remaining)
+		}
+		if remaining == 0 {
+			if wait, ok := parseRateLimitReset(response.Header.Get(RATE_LIMIT_RESET_HEADER), now); ok {
+				limiter.pauseUntil(now.Add(wait))
+			}
+		}
+	}
+	if response.StatusCode == http.StatusTooManyRequests {
+		if wait, ok := parseRetryAfter(response.Header.Get("Retry-After"), now); ok {
+			limiter.pauseUntil(now.Add(wait))
+		}
+	}
+}
+
+// parseRateLimitReset parses a X-RateLimit-Reset header, either a number of seconds or a unix time, into how long to
+// wait from now, capped to RATE_LIMIT_MAX_PAUSE
+func parseRateLimitReset(value string, now time.Time) (time.Duration, bool) {
+	reset, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || reset <= 0 {
+		return 0, false
+	}
+	var wait time.Duration
+	if reset >= RATE_LIMIT_RESET_EPOCH {
+		wait = time.Unix(reset, 0).Sub(now)
+	} else if reset < int64(RATE_LIMIT_MAX_PAUSE/time.Second) {
+		wait = time.Duration(reset) * time.Second
+	} else {
+		wait = RATE_LIMIT_MAX_PAUSE
+	}
+	if wait <= 0 {
+		return 0, false
+	}
+	if wait > RATE_LIMIT_MAX_PAUSE {
+		wait = RATE_LIMIT_MAX_PAUSE
+	}
+	return wait, true
+}
+
+func (limiter *RateLimiter) pauseUntil(until time.Time) {
+	if until.After(limiter.pausedUntil) {
+		limiter.pausedUntil = until
+	}
+}
+
+// Tokens returns the number of requests which can currently be sent without waiting
+func (limiter *RateLimiter) Tokens() float64 {
+	return limiter.Stats().Tokens
+}
+
+// Stats returns a snapshot of the state of the limiter
+func (limiter *RateLimiter) Stats() RateLimiterStats {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	limiter.refill(time.Now())
+	return RateLimiterStats{
+		Tokens:      limiter.tokens,
+		Waits:       limiter.waits,
+		TotalWait:   limiter.totalWait,
+		PausedUntil: limiter.pausedUntil,
+	}
+}
@@ -0,0 +1,116 @@
+package insight_goclient
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_NewRateLimiterValidatesInput(t *testing.T) {
+	_, err := NewRateLimiter(0, 1)
+	assert.NotNil(t, err)
+	_, err = NewRateLimiter(1, 0)
+	assert.NotNil(t, err)
+}
+
+func TestRateLimiter_WaitConsumesTokens(t *testing.T) {
+	limiter, err := NewRateLimiter(100, 2)
+	assert.Nil(t, err)
+	assert.Nil(t, limiter.Wait(context.Background()))
+	assert.Nil(t, limiter.Wait(context.Background()))
+	assert.True(t, limiter.Tokens() < 1)
+
+	start := time.Now()
+	assert.Nil(t, limiter.Wait(context.Background()))
+	assert.True(t, time.Since(start) >= 5*time.Millisecond)
+	stats := limiter.Stats()
+	assert.Equal(t, 1, stats.Waits)
+	assert.True(t, stats.TotalWait > 0)
+}
+
+func TestRateLimiter_WaitHonorsContext(t *testing.T) {
+	limiter, _ := NewRateLimiter(0.001, 1)
+	assert.Nil(t, limiter.Wait(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, limiter.Wait(ctx))
+}
+
+func TestRateLimiter_ObservePausesUntilReset(t *testing.T) {
+	limiter, _ := NewRateLimiter(1000, 10)
+	response := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	response.Header.Set(RATE_LIMIT_REMAINING_HEADER, "0")
+	response.Header.Set(RATE_LIMIT_RESET_HEADER, "30")
+	limiter.Observe(response)
+
+	stats := limiter.Stats()
+	assert.True(t, stats.Tokens < 1)
+	assert.True(t, time.Until(stats.PausedUntil) > 25*time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, limiter.Wait(ctx))
+}
+
+func TestRateLimiter_ParseRateLimitReset(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	wait, ok := parseRateLimitReset("30", now)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, wait)
+
+	wait, ok = parseRateLimitReset("1700000045", now)
+	assert.True(t, ok)
+	assert.Equal(t, 45*time.Second, wait)
+
+	wait, ok = parseRateLimitReset("1800000000", now)
+	assert.True(t, ok)
+	assert.Equal(t, RATE_LIMIT_MAX_PAUSE, wait)
+
+	wait, ok = parseRateLimitReset("999999999", now)
+	assert.True(t, ok)
+	assert.Equal(t, RATE_LIMIT_MAX_PAUSE, wait)
+
+	for _, value := range []string{"", "soon", "0", "-5", "1699999990"} {
+		_, ok = parseRateLimitReset(value, now)
+		assert.False(t, ok, value)
+	}
+}
+
+func TestRateLimiter_ObserveTooManyRequests(t *testing.T) {
+	limiter, _ := NewRateLimiter(1000, 10)
+	response := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	response.Header.Set("Retry-After", "5")
+	limiter.Observe(response)
+	assert.True(t, time.Until(limiter.Stats().PausedUntil) > 4*time.Second)
+}
+
+func TestRateLimiter_SharedAcrossGoroutines(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer httpServer.Close()
+	limiter, _ := NewRateLimiter(1000, 5)
+	c, err := NewInsightClientWithOptions("apiKey", "", WithBaseUrl(httpServer.URL), WithRateLimiter(limiter))
+	assert.Nil(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, c.DeleteLog("log-uuid"))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 20, requests)
+	assert.True(t, limiter.Stats().Waits > 0)
+}
@@ -0,0 +1,76 @@
+package insight_goclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	REGION_US  = "us"
+	REGION_EU  = "eu"
+	REGION_CA  = "ca"
+	REGION_AU  = "au"
+	REGION_AP  = "ap"
+	REGION_US2 = "us2"
+	REGION_US3 = "us3"
+)
+
+// Regions lists the known insight regions, each of them being served by its own rest endpoint
+var Regions = []string{REGION_US, REGION_EU, REGION_CA, REGION_AU, REGION_AP, REGION_US2, REGION_US3}
+
+// ValidateRegion checks that region is one of the known insight regions
+func ValidateRegion(region string) error {
+	for _, knownRegion := range Regions {
+		if region == knownRegion {
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown region %s, expected one of: %s", region, strings.Join(Regions, ", "))
+}
+
+// validateUnlistedRegion checks that a region missing from Regions can be used in the host name of its endpoints
+func validateUnlistedRegion(region string) error {
+	if region == "" {
+		return fmt.Errorf("Invalid region, it must not be empty")
+	}
+	for _, char := range region {
+		if (char < 'a' || char > 'z') && (char < '0' || char > '9') && char != '-' {
+			return fmt.Errorf("Invalid region %s, only lowercase letters, digits and dashes are allowed", region)
+		}
+	}
+	return nil
+}
+
+// DetectRegion finds the region the api key belongs to by probing the rest endpoint of each known region
+func DetectRegion(apiKey string, options ...ClientOption) (string, error) {
+	return DetectRegionContext(context.Background(), apiKey, options...)
+}
+
+// DetectRegionContext finds the region the api key belongs to by probing the rest endpoint of each known region
+// using the provided context. Probes are not retried, and only a 401, 403 or 404 response moves on to the next region.
+func DetectRegionContext(ctx context.Context, apiKey string, options ...ClientOption) (string, error) {
+	probeOptions := append(append([]ClientOption{}, options...), WithRetryPolicy(nil))
+	for _, region := range Regions {
+		client, err := NewInsightClientWithOptions(apiKey, region, probeOptions...)
+		if err != nil {
+			return "", err
+		}
+		if client.InsightUrl != fmt.Sprintf(INSIGHT_API, region) {
+			return "", fmt.Errorf("Region detection cannot be used along with a custom base url")
+		}
+		var labels Labels
+		err = client.getWithContext(ctx, LABELS_PATH, &labels)
+		if err == nil {
+			return region, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		// Only a rejected api key means it belongs to another region, any other failure is reported as is
+		if !IsUnauthorized(err) && !IsNotFound(err) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("Unable to detect the region of the api key, none of the regions accepted it: %s", strings.Join(Regions, ", "))
+}
@@ -0,0 +1,100 @@
+package insight_goclient
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// regionRoundTripper fakes the rest endpoints of every region, only accepting the api key in acceptedRegion
+type regionRoundTripper struct {
+	acceptedRegion string
+	// failingRegion answers 503 rather than rejecting the api key
+	failingRegion string
+	probedHosts   []string
+}
+
+func (rt *regionRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	rt.probedHosts = append(rt.probedHosts, request.URL.Host)
+	statusCode := http.StatusForbidden
+	if request.URL.Host == rt.acceptedRegion+".rest.logs.insight.rapid7.com" {
+		statusCode = http.StatusOK
+	}
+	if request.URL.Host == rt.failingRegion+".rest.logs.insight.rapid7.com" {
+		statusCode = http.StatusServiceUnavailable
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader(`{"labels":[]}`)),
+		Request:    request,
+	}, nil
+}
+
+func TestRegion_ValidateRegion(t *testing.T) {
+	for _, region := range Regions {
+		assert.Nil(t, ValidateRegion(region))
+	}
+	err := ValidateRegion("eu1")
+	assert.NotNil(t, err)
+	assert.Equal(t, "Unknown region eu1, expected one of: us, eu, ca, au, ap, us2, us3", err.Error())
+}
+
+func TestRegion_NewInsightClientValidatesRegion(t *testing.T) {
+	_, err := NewInsightClient("apiKey", "eu1")
+	assert.NotNil(t, err)
+	_, err = NewInsightClientWithOptions("apiKey", "", WithBaseUrl("http://localhost:8080"))
+	assert.Nil(t, err)
+}
+
+func TestRegion_DetectRegion(t *testing.T) {
+	roundTripper := &regionRoundTripper{acceptedRegion: REGION_CA}
+	region, err := DetectRegion("apiKey", WithHttpClient(&http.Client{Transport: roundTripper}))
+	assert.Nil(t, err)
+	assert.Equal(t, REGION_CA, region)
+	assert.Equal(t, []string{"us.rest.logs.insight.rapid7.com", "eu.rest.logs.insight.rapid7.com", "ca.rest.logs.insight.rapid7.com"}, roundTripper.probedHosts)
+}
+
+func TestRegion_DetectRegionFails(t *testing.T) {
+	roundTripper := &regionRoundTripper{}
+	_, err := DetectRegion("apiKey", WithHttpClient(&http.Client{Transport: roundTripper}))
+	assert.NotNil(t, err)
+	assert.Len(t, roundTripper.probedHosts, len(Regions))
+
+	_, err = DetectRegion("apiKey", WithBaseUrl("http://localhost:8080"))
+	assert.NotNil(t, err)
+}
+
+func TestRegion_DetectRegionReturnsOtherErrors(t *testing.T) {
+	roundTripper := &regionRoundTripper{acceptedRegion: REGION_CA, failingRegion: REGION_EU}
+	_, err := DetectRegion("apiKey", WithHttpClient(&http.Client{Transport: roundTripper}))
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, err.(*APIError).StatusCode)
+	assert.Equal(t, []string{"us.rest.logs.insight.rapid7.com", "eu.rest.logs.insight.rapid7.com"}, roundTripper.probedHosts)
+}
+
+func TestRegion_DetectRegionContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := DetectRegionContext(ctx, "apiKey")
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestRegion_NewInsightClientAcceptsAllRegions(t *testing.T) {
+	c, err := NewInsightClient("apiKey", "us2")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://us2.rest.logs.insight.rapid7.com", c.InsightUrl)
+	_, err = NewInsightClient("apiKey", "us3")
+	assert.Nil(t, err)
+
+	_, err = NewInsightClient("apiKey", "eu2")
+	assert.NotNil(t, err)
+	c, err = NewInsightClientWithOptions("apiKey", "eu2", WithUnlistedRegion())
+	assert.Nil(t, err)
+	assert.Equal(t, "https://eu2.rest.logs.insight.rapid7.com", c.InsightUrl)
+	_, err = NewInsightClientWithOptions("apiKey", "evil.com/", WithUnlistedRegion())
+	assert.NotNil(t, err)
+}
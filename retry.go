@@ -0,0 +1,232 @@
+package insight_goclient
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy describes how the insight client retries requests that failed with a transport error or an
+// unexpected status code. A nil RetryPolicy on the client disables retries altogether.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+	// MinBackoff is the wait before the first retry; it doubles on every subsequent retry
+	MinBackoff time.Duration
+	// MaxBackoff caps the exponential backoff
+	MaxBackoff time.Duration
+	// MaxRetryAfter caps the Retry-After headers sent by the api which are honored; retries stop, returning the
+	// error, when the api asks to wait longer. MaxBackoff is used when zero
+	MaxRetryAfter time.Duration
+	// RetryableMethods lists the http methods which are safe to be retried
+	RetryableMethods []string
+	// ShouldRetry decides whether a failed attempt is retried. The response body has already been consumed when
+	// it is called. When nil, DefaultShouldRetry is used
+	ShouldRetry func(request *http.Request, response *http.Response, err error) bool
+}
+
+// DefaultRetryPolicy returns the policy used by clients created via NewInsightClient. Only idempotent methods
+// (GET, PUT and DELETE) are retried.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:      4,
+		MinBackoff:       500 * time.Millisecond,
+		MaxBackoff:       30 * time.Second,
+		MaxRetryAfter:    2 * time.Minute,
+		RetryableMethods: []string{http.MethodGet, http.MethodPut, http.MethodDelete},
+	}
+}
+
+// DefaultShouldRetry retries transport errors, rate limited responses (429) and server side errors (5xx) except
+// 501 Not Implemented. Cancelled or expired contexts are never retried.
+func DefaultShouldRetry(request *http.Request, response *http.Response, err error) bool {
+	if err != nil {
+		return request.Context().Err() == nil
+	}
+	if response == nil {
+		return false
+	}
+	if response.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return response.StatusCode >= http.StatusInternalServerError && response.StatusCode != http.StatusNotImplemented
+}
+
+// WithRetryPolicy sets the retry policy of the client; a nil policy disables retries
+func WithRetryPolicy(policy *RetryPolicy) ClientOption {
+	return func(options *clientOptions) error {
+		if policy != nil {
+			if err := policy.validate(); err != nil {
+				return err
+			}
+		}
+		options.retryPolicy = policy
+		options.retryPolicySet = true
+		return nil
+	}
+}
+
+func (policy *RetryPolicy) validate() error {
+	if policy.MaxAttempts < 1 {
+		return fmt.Errorf("RetryPolicy MaxAttempts must be at least 1, got %d", policy.MaxAttempts)
+	}
+	if policy.MinBackoff < 0 {
+		return fmt.Errorf("RetryPolicy MinBackoff must not be negative, got %s", policy.MinBackoff)
+	}
+	if policy.MaxBackoff < policy.MinBackoff {
+		return fmt.Errorf("RetryPolicy MaxBackoff (%s) must not be lower than MinBackoff (%s)", policy.MaxBackoff, policy.MinBackoff)
+	}
+	if policy.MaxRetryAfter < 0 {
+		return fmt.Errorf("RetryPolicy MaxRetryAfter must not be negative, got %s", policy.MaxRetryAfter)
+	}
+	return nil
+}
+
+// shouldRetry checks whether another attempt must be made after the given (failed) attempt
+func (policy *RetryPolicy) shouldRetry(attempt int, request *http.Request, response *http.Response, err error) bool {
+	if policy == nil || attempt >= policy.MaxAttempts || !policy.isRetryableMethod(request.Method) {
+		return false
+	}
+	if request.Body != nil && request.GetBody == nil {
+		return false
+	}
+	if wait, ok := retryAfter(response); ok && wait > policy.maxRetryAfter() {
+		return false
+	}
+	shouldRetry := policy.ShouldRetry
+	if shouldRetry == nil {
+		shouldRetry = DefaultShouldRetry
+	}
+	return shouldRetry(request, response, err)
+}
+
+func (policy *RetryPolicy) isRetryableMethod(method string) bool {
+	for _, retryableMethod := range policy.RetryableMethods {
+		if retryableMethod == method {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns how long to wait before the next attempt. A Retry-After header takes precedence, capped to
+// MaxRetryAfter, otherwise an exponential backoff with jitter is used
+func (policy *RetryPolicy) backoff(attempt int, response *http.Response) time.Duration {
+	if wait, ok := retryAfter(response); ok {
+		if wait > policy.maxRetryAfter() {
+			return policy.maxRetryAfter()
+		}
+		return wait
+	}
+	backoff := exponentialBackoff(policy.MinBackoff, attempt-1, policy.MaxBackoff)
+	if backoff <= 0 {
+		return 0
+	}
+	// Equal jitter: wait at least half of the backoff so that retries keep spreading out
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(backoff-half)+1))
+}
+
+func (policy *RetryPolicy) maxRetryAfter() time.Duration {
+	if policy.MaxRetryAfter == 0 {
+		return policy.MaxBackoff
+	}
+	return policy.MaxRetryAfter
+}
+
+// retryAfter returns the wait asked by the Retry-After header of the response, if any
+func retryAfter(response *http.Response) (time.Duration, bool) {
+	if response == nil {
+		return 0, false
+	}
+	return parseRetryAfter(response.Header.Get("Retry-After"), time.Now())
+}
+
+// parseRetryAfter parses a Retry-After header expressed either in seconds or as an http date
+func parseRetryAfter(retryAfter string, now time.Time) (time.Duration, bool) {
+	if retryAfter == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(retryAfter); err == nil {
+		wait := date.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// exponentialBackoff doubles base for every failure, capping the result to max
+func exponentialBackoff(base time.Duration, failures int, max time.Duration) time.Duration {
+	backoff := base
+	for i := 0; i < failures && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		backoff = max
+	}
+	return backoff
+}
+
+// sendWithRetries makes attempts at sending the request until one gets one of the expected response codes or the
+// policy gives up, waiting between them. attempt sends the request once and returns the response along with its fully
+// read body.
+func sendWithRetries(policy *RetryPolicy, request *http.Request, attempt func() (*http.Response, []byte, error),
+	expectedResponseCodes ...int) (*http.Response, []byte, error) {
+	for attempts := 1; ; attempts++ {
+		response, body, err := attempt()
+		if err == nil && containsStatusCode(expectedResponseCodes, response.StatusCode) {
+			return response, body, nil
+		}
+		if !policy.shouldRetry(attempts, request, response, err) {
+			if err != nil {
+				return nil, nil, err
+			}
+			return nil, nil, newAPIError(request, response, expectedResponseCodes[0], body)
+		}
+		if err := sleepContext(request.Context(), policy.backoff(attempts, response)); err != nil {
+			return nil, nil, err
+		}
+		if err := rewindBody(request); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// rewindBody resets the request body so that it can be sent again
+func rewindBody(request *http.Request) error {
+	if request.Body == nil || request.GetBody == nil {
+		return nil
+	}
+	body, err := request.GetBody()
+	if err != nil {
+		return err
+	}
+	request.Body = body
+	return nil
+}
+
+// sleepContext waits for the given duration unless the context is done first
+func sleepContext(ctx context.Context, duration time.Duration) error {
+	if duration <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
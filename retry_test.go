@@ -0,0 +1,156 @@
+package insight_goclient
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func getTestRetryPolicy() *RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.MinBackoff = time.Millisecond
+	policy.MaxBackoff = 5 * time.Millisecond
+	return policy
+}
+
+// getFlakyTestClient returns a client whose server fails with failureStatusCode the first failures times before
+// answering with successStatusCode. Every request body received is recorded in bodies.
+func getFlakyTestClient(failures, failureStatusCode, successStatusCode int, bodies *[]string) (*InsightClient, *httptest.Server) {
+	attempts := 0
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		*bodies = append(*bodies, string(body))
+		attempts++
+		if attempts <= failures {
+			w.WriteHeader(failureStatusCode)
+			return
+		}
+		w.WriteHeader(successStatusCode)
+		w.Write([]byte("{}"))
+	}))
+	c := &InsightClient{InsightUrl: httpServer.URL, ApiKey: "apikey", HttpClient: &http.Client{}, RetryPolicy: getTestRetryPolicy()}
+	return c, httpServer
+}
+
+func TestRetry_GetIsRetriedOnServerErrors(t *testing.T) {
+	var bodies []string
+	c, httpServer := getFlakyTestClient(2, http.StatusServiceUnavailable, http.StatusOK, &bodies)
+	defer httpServer.Close()
+	err := c.get("/api/testing", &mockObject{})
+	assert.Nil(t, err)
+	assert.Len(t, bodies, 3)
+}
+
+func TestRetry_GetGivesUpAfterMaxAttempts(t *testing.T) {
+	var bodies []string
+	c, httpServer := getFlakyTestClient(10, http.StatusTooManyRequests, http.StatusOK, &bodies)
+	defer httpServer.Close()
+	err := c.get("/api/testing", &mockObject{})
+	assert.NotNil(t, err)
+	assert.Len(t, bodies, c.RetryPolicy.MaxAttempts)
+}
+
+func TestRetry_PutBodyIsReplayed(t *testing.T) {
+	var bodies []string
+	c, httpServer := getFlakyTestClient(1, http.StatusBadGateway, http.StatusOK, &bodies)
+	defer httpServer.Close()
+	_, err := c.put("/api/testing", &mockObject{Data: "some data..."})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`{"data":"some data..."}`, `{"data":"some data..."}`}, bodies)
+}
+
+func TestRetry_PostIsNotRetried(t *testing.T) {
+	var bodies []string
+	c, httpServer := getFlakyTestClient(1, http.StatusServiceUnavailable, http.StatusCreated, &bodies)
+	defer httpServer.Close()
+	_, err := c.post("/api/testing", &mockObject{})
+	assert.NotNil(t, err)
+	assert.Len(t, bodies, 1)
+}
+
+func TestRetry_ClientErrorsAreNotRetried(t *testing.T) {
+	var bodies []string
+	c, httpServer := getFlakyTestClient(1, http.StatusNotFound, http.StatusOK, &bodies)
+	defer httpServer.Close()
+	err := c.get("/api/testing", &mockObject{})
+	assert.NotNil(t, err)
+	assert.Len(t, bodies, 1)
+}
+
+func TestRetry_CustomShouldRetry(t *testing.T) {
+	var bodies []string
+	c, httpServer := getFlakyTestClient(1, http.StatusConflict, http.StatusNoContent, &bodies)
+	defer httpServer.Close()
+	c.RetryPolicy.ShouldRetry = func(request *http.Request, response *http.Response, err error) bool {
+		return response != nil && response.StatusCode == http.StatusConflict
+	}
+	err := c.delete("/api/testing")
+	assert.Nil(t, err)
+	assert.Len(t, bodies, 2)
+}
+
+func TestRetry_NilPolicyDisablesRetries(t *testing.T) {
+	var bodies []string
+	c, httpServer := getFlakyTestClient(1, http.StatusServiceUnavailable, http.StatusOK, &bodies)
+	defer httpServer.Close()
+	c.RetryPolicy = nil
+	err := c.get("/api/testing", &mockObject{})
+	assert.NotNil(t, err)
+	assert.Len(t, bodies, 1)
+}
+
+func TestRetry_BackoffHonorsRetryAfter(t *testing.T) {
+	policy := getTestRetryPolicy()
+	response := &http.Response{Header: http.Header{}}
+	response.Header.Set("Retry-After", "2")
+	assert.Equal(t, 2*time.Second, policy.backoff(1, response))
+
+	backoff := policy.backoff(10, nil)
+	assert.True(t, backoff >= policy.MaxBackoff/2 && backoff <= policy.MaxBackoff)
+}
+
+func TestRetry_LongRetryAfterStopsRetries(t *testing.T) {
+	attempts := 0
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer httpServer.Close()
+	c := &InsightClient{InsightUrl: httpServer.URL, ApiKey: "apikey", HttpClient: &http.Client{}, RetryPolicy: getTestRetryPolicy()}
+	start := time.Now()
+	err := c.get("/api/testing", &mockObject{})
+	assert.True(t, IsRateLimited(err))
+	assert.Equal(t, 1, attempts)
+	assert.True(t, time.Since(start) < time.Second)
+
+	policy := &RetryPolicy{MaxAttempts: 2, MaxBackoff: time.Second}
+	response := &http.Response{Header: http.Header{}}
+	response.Header.Set("Retry-After", "3600")
+	assert.Equal(t, time.Second, policy.backoff(1, response))
+}
+
+func TestRetry_ParseRetryAfter(t *testing.T) {
+	now := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+	wait, ok := parseRetryAfter(now.Add(3*time.Second).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, wait)
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(t, ok)
+	_, ok = parseRetryAfter("", now)
+	assert.False(t, ok)
+}
+
+func TestRetry_WithRetryPolicyValidatesPolicy(t *testing.T) {
+	_, err := NewInsightClientWithOptions("apiKey", "eu", WithRetryPolicy(&RetryPolicy{MaxAttempts: 0}))
+	assert.NotNil(t, err)
+	c, err := NewInsightClientWithOptions("apiKey", "eu", WithRetryPolicy(nil))
+	assert.Nil(t, err)
+	assert.Nil(t, c.RetryPolicy)
+	c, err = NewInsightClient("apiKey", "eu")
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultRetryPolicy(), c.RetryPolicy)
+}
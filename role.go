@@ -0,0 +1,117 @@
+package insight_goclient
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	ROLES_PATH = "/management/roles"
+)
+
+// The Roles resource allows you to retrieve the Roles which can be assigned to the Users of your account. The
+// following operations are supported:
+// - Get details of an existing Role
+// - Get details of a list of all Roles
+
+// Role represents the entity used to get an existing role from the insight API
+type Role struct {
+	Id          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type Roles struct {
+	Roles []*Role `json:"roles"`
+}
+
+type RoleRequest struct {
+	Role *Role `json:"role"`
+}
+
+// GetRoles gets details of a list of all Roles
+func (client *InsightClient) GetRoles() ([]*Role, error) {
+	return client.GetRolesContext(context.Background())
+}
+
+// GetRolesContext gets details of a list of all Roles using the provided context
+func (client *InsightClient) GetRolesContext(ctx context.Context) ([]*Role, error) {
+	var result []*Role
+	err := client.ForEachRoleContext(ctx, func(role *Role) error {
+		result = append(result, role)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ForEachRole calls fn for every Role of the account, fetching them page by page
+func (client *InsightClient) ForEachRole(fn func(role *Role) error) error {
+	return client.ForEachRoleContext(context.Background(), fn)
+}
+
+// ForEachRoleContext calls fn for every Role of the account, fetching them page by page using the provided
+// context. Iteration stops at the first error returned by fn; return ErrStopIteration to stop without failing
+func (client *InsightClient) ForEachRoleContext(ctx context.Context, fn func(role *Role) error) error {
+	var roles Roles
+	return client.getPages(ctx, ROLES_PATH, &roles, func() error {
+		for _, role := range roles.Roles {
+			if err := fn(role); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetRole gets a specific Role from an account
+func (client *InsightClient) GetRole(roleId string) (*Role, error) {
+	return client.GetRoleContext(context.Background(), roleId)
+}
+
+// GetRoleContext gets a specific Role from an account using the provided context
+func (client *InsightClient) GetRoleContext(ctx context.Context, roleId string) (*Role, error) {
+	var roleRequest RoleRequest
+	endpoint, err := client.getRoleEndpoint(roleId)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.getWithContext(ctx, endpoint, &roleRequest); err != nil {
+		return nil, err
+	}
+	return roleRequest.Role, nil
+}
+
+// GetRoleByName gets the Role from an account with the given name
+func (client *InsightClient) GetRoleByName(name string) (*Role, error) {
+	return client.GetRoleByNameContext(context.Background(), name)
+}
+
+// GetRoleByNameContext gets the Role from an account with the given name using the provided context
+func (client *InsightClient) GetRoleByNameContext(ctx context.Context, name string) (*Role, error) {
+	var result *Role
+	err := client.ForEachRoleContext(ctx, func(role *Role) error {
+		if role.Name == name {
+			result = role
+			return ErrStopIteration
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("No role with name %s exists", name)
+	}
+	return result, nil
+}
+
+func (client *InsightClient) getRoleEndpoint(roleId string) (string, error) {
+	if roleId == "" {
+		return "", fmt.Errorf("roleId input parameter is mandatory")
+	} else {
+		return fmt.Sprintf("%s/%s", ROLES_PATH, roleId), nil
+	}
+}
@@ -0,0 +1,49 @@
+package insight_goclient
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func getTestRoles() []*Role {
+	return []*Role{
+		{Id: "admin-role-uuid", Name: "Administrator"},
+		{Id: "read-only-role-uuid", Name: "Read Only", Description: "Can search logs"},
+	}
+}
+
+func TestRoles_GetRoles(t *testing.T) {
+	expectedRoles := getTestRoles()
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/management/roles", nil, http.StatusOK, Roles{expectedRoles})
+	client := getTestClient(requestMatcher)
+	returnedRoles, err := client.GetRoles()
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedRoles, returnedRoles)
+}
+
+func TestRoles_GetRole(t *testing.T) {
+	expectedRole := getTestRoles()[0]
+	url := fmt.Sprintf("/management/roles/%s", expectedRole.Id)
+	requestMatcher := NewRequestMatcher(http.MethodGet, url, nil, http.StatusOK, RoleRequest{expectedRole})
+	client := getTestClient(requestMatcher)
+	returnedRole, err := client.GetRole(expectedRole.Id)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedRole, returnedRole)
+
+	_, err = client.GetRole("")
+	assert.Error(t, err, "roleId input parameter is mandatory")
+}
+
+func TestRoles_GetRoleByName(t *testing.T) {
+	roles := getTestRoles()
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/management/roles", nil, http.StatusOK, Roles{roles})
+	client := getTestClient(requestMatcher)
+	returnedRole, err := client.GetRoleByName("Read Only")
+	assert.Nil(t, err)
+	assert.EqualValues(t, roles[1], returnedRole)
+
+	_, err = client.GetRoleByName("Missing")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,178 @@
+package insight_goclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	SAVED_QUERIES_PATH = "/query/saved_queries"
+)
+
+// The Saved Queries resource allows you to interact with Saved Queries in your account. The following operations are
+// supported:
+// - Get details of an existing Saved Query
+// - Get details of a list of all Saved Queries
+// - Create a new Saved Query
+// - Update an existing Saved Query
+// - Delete a Saved Query
+
+// SavedQuery represents the entity used to get an existing saved query from the insight API
+type SavedQuery struct {
+	Id   string   `json:"id,omitempty"`
+	Name string   `json:"name"`
+	Logs []string `json:"logs,omitempty"`
+	Leql *Leql    `json:"leql"`
+}
+
+type SavedQueries struct {
+	SavedQueries []*SavedQuery `json:"saved_queries"`
+}
+
+type SavedQueryRequest struct {
+	SavedQuery *SavedQuery `json:"saved_query"`
+}
+
+// GetSavedQueries gets details of a list of all Saved Queries
+func (client *InsightClient) GetSavedQueries() ([]*SavedQuery, error) {
+	return client.GetSavedQueriesContext(context.Background())
+}
+
+// GetSavedQueriesContext gets details of a list of all Saved Queries using the provided context
+func (client *InsightClient) GetSavedQueriesContext(ctx context.Context) ([]*SavedQuery, error) {
+	var result []*SavedQuery
+	err := client.ForEachSavedQueryContext(ctx, func(savedQuery *SavedQuery) error {
+		result = append(result, savedQuery)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ForEachSavedQuery calls fn for every SavedQuery of the account, fetching them page by page
+func (client *InsightClient) ForEachSavedQuery(fn func(savedQuery *SavedQuery) error) error {
+	return client.ForEachSavedQueryContext(context.Background(), fn)
+}
+
+// ForEachSavedQueryContext calls fn for every SavedQuery of the account, fetching them page by page using the
+// provided context. Iteration stops at the first error returned by fn; return ErrStopIteration to stop without failing
+func (client *InsightClient) ForEachSavedQueryContext(ctx context.Context, fn func(savedQuery *SavedQuery) error) error {
+	var savedQueries SavedQueries
+	return client.getPages(ctx, SAVED_QUERIES_PATH, &savedQueries, func() error {
+		for _, savedQuery := range savedQueries.SavedQueries {
+			if err := fn(savedQuery); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetSavedQuery gets a specific Saved Query from an account
+func (client *InsightClient) GetSavedQuery(savedQueryId string) (*SavedQuery, error) {
+	return client.GetSavedQueryContext(context.Background(), savedQueryId)
+}
+
+// GetSavedQueryContext gets a specific Saved Query from an account using the provided context
+func (client *InsightClient) GetSavedQueryContext(ctx context.Context, savedQueryId string) (*SavedQuery, error) {
+	var savedQueryRequest SavedQueryRequest
+	endpoint, err := client.getSavedQueryEndpoint(savedQueryId)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.getWithContext(ctx, endpoint, &savedQueryRequest); err != nil {
+		return nil, err
+	}
+	return savedQueryRequest.SavedQuery, nil
+}
+
+// GetSavedQueryByName gets a specific Saved Query from an account by name
+func (client *InsightClient) GetSavedQueryByName(name string) (*SavedQuery, error) {
+	return client.GetSavedQueryByNameContext(context.Background(), name)
+}
+
+// GetSavedQueryByNameContext gets a specific Saved Query from an account by name using the provided context
+func (client *InsightClient) GetSavedQueryByNameContext(ctx context.Context, name string) (*SavedQuery, error) {
+	var result *SavedQuery
+	err := client.ForEachSavedQueryContext(ctx, func(savedQuery *SavedQuery) error {
+		if savedQuery.Name == name {
+			result = savedQuery
+			return ErrStopIteration
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("No saved query with name %s exists", name)
+	}
+	return result, nil
+}
+
+// PostSavedQuery creates a new Saved Query
+func (client *InsightClient) PostSavedQuery(savedQuery *SavedQuery) error {
+	return client.PostSavedQueryContext(context.Background(), savedQuery)
+}
+
+// PostSavedQueryContext creates a new Saved Query using the provided context
+func (client *InsightClient) PostSavedQueryContext(ctx context.Context, savedQuery *SavedQuery) error {
+	savedQueryRequest := SavedQueryRequest{savedQuery}
+	resp, err := client.postWithContext(ctx, SAVED_QUERIES_PATH, savedQueryRequest)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(resp, &savedQueryRequest)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// PutSavedQuery updates an existing Saved Query
+func (client *InsightClient) PutSavedQuery(savedQuery *SavedQuery) error {
+	return client.PutSavedQueryContext(context.Background(), savedQuery)
+}
+
+// PutSavedQueryContext updates an existing Saved Query using the provided context
+func (client *InsightClient) PutSavedQueryContext(ctx context.Context, savedQuery *SavedQuery) error {
+	savedQueryRequest := SavedQueryRequest{savedQuery}
+	endpoint, err := client.getSavedQueryEndpoint(savedQuery.Id)
+	if err != nil {
+		return err
+	}
+	resp, err := client.putWithContext(ctx, endpoint, savedQueryRequest)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(resp, &savedQueryRequest)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteSavedQuery deletes a specific Saved Query from an account.
+func (client *InsightClient) DeleteSavedQuery(savedQueryId string) error {
+	return client.DeleteSavedQueryContext(context.Background(), savedQueryId)
+}
+
+// DeleteSavedQueryContext deletes a specific Saved Query from an account using the provided context
+func (client *InsightClient) DeleteSavedQueryContext(ctx context.Context, savedQueryId string) error {
+	endpoint, err := client.getSavedQueryEndpoint(savedQueryId)
+	if err != nil {
+		return err
+	}
+	return client.deleteWithContext(ctx, endpoint)
+}
+
+func (client *InsightClient) getSavedQueryEndpoint(savedQueryId string) (string, error) {
+	if savedQueryId == "" {
+		return "", fmt.Errorf("savedQueryId input parameter is mandatory")
+	} else {
+		return fmt.Sprintf("%s/%s", SAVED_QUERIES_PATH, savedQueryId), nil
+	}
+}
@@ -0,0 +1,95 @@
+package insight_goclient
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func getTestSavedQuery() *SavedQuery {
+	return &SavedQuery{
+		Id:   "saved-query-uuid",
+		Name: "Server errors",
+		Logs: []string{"log-uuid"},
+		Leql: &Leql{
+			Statement: "where(status>=500) calculate(count)",
+			During:    &During{TimeRange: "last 24 hours"},
+		},
+	}
+}
+
+func TestSavedQueries_GetSavedQueries(t *testing.T) {
+	expectedSavedQueries := []*SavedQuery{getTestSavedQuery()}
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/query/saved_queries", nil, http.StatusOK, SavedQueries{expectedSavedQueries})
+	client := getTestClient(requestMatcher)
+	returnedSavedQueries, err := client.GetSavedQueries()
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedSavedQueries, returnedSavedQueries)
+}
+
+func TestSavedQueries_GetSavedQuery(t *testing.T) {
+	expectedSavedQuery := getTestSavedQuery()
+	url := fmt.Sprintf("/query/saved_queries/%s", expectedSavedQuery.Id)
+	requestMatcher := NewRequestMatcher(http.MethodGet, url, nil, http.StatusOK, SavedQueryRequest{expectedSavedQuery})
+	client := getTestClient(requestMatcher)
+	returnedSavedQuery, err := client.GetSavedQuery(expectedSavedQuery.Id)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedSavedQuery, returnedSavedQuery)
+}
+
+func TestSavedQueries_GetSavedQueryErrorsIfSavedQueryIdIsEmpty(t *testing.T) {
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/query/saved_queries/", nil, http.StatusOK, SavedQueryRequest{&SavedQuery{}})
+	client := getTestClient(requestMatcher)
+	_, err := client.GetSavedQuery("")
+	assert.NotNil(t, err)
+	assert.Error(t, err, "savedQueryId input parameter is mandatory")
+}
+
+func TestSavedQueries_GetSavedQueryByName(t *testing.T) {
+	expectedSavedQuery := getTestSavedQuery()
+	savedQueries := []*SavedQuery{{Id: "other-uuid", Name: "Other"}, expectedSavedQuery}
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/query/saved_queries", nil, http.StatusOK, SavedQueries{savedQueries})
+	client := getTestClient(requestMatcher)
+	returnedSavedQuery, err := client.GetSavedQueryByName("Server errors")
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedSavedQuery, returnedSavedQuery)
+
+	_, err = client.GetSavedQueryByName("Missing")
+	assert.NotNil(t, err)
+}
+
+func TestSavedQueries_PostSavedQuery(t *testing.T) {
+	p := getTestSavedQuery()
+	p.Id = ""
+	expectedSavedQuery := getTestSavedQuery()
+
+	requestMatcher := NewRequestMatcher(http.MethodPost, "/query/saved_queries", SavedQueryRequest{p}, http.StatusCreated, SavedQueryRequest{expectedSavedQuery})
+	client := getTestClient(requestMatcher)
+	err := client.PostSavedQuery(p)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedSavedQuery, p)
+}
+
+func TestSavedQueries_PutSavedQuery(t *testing.T) {
+	p := getTestSavedQuery()
+	p.Name = "Renamed"
+	expectedSavedQuery := getTestSavedQuery()
+	expectedSavedQuery.Name = "Renamed"
+
+	url := fmt.Sprintf("/query/saved_queries/%s", p.Id)
+	requestMatcher := NewRequestMatcher(http.MethodPut, url, SavedQueryRequest{p}, http.StatusOK, SavedQueryRequest{expectedSavedQuery})
+	client := getTestClient(requestMatcher)
+	err := client.PutSavedQuery(p)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedSavedQuery, p)
+}
+
+func TestSavedQueries_DeleteSavedQuery(t *testing.T) {
+	savedQueryId := "saved-query-uuid"
+	url := fmt.Sprintf("/query/saved_queries/%s", savedQueryId)
+	requestMatcher := NewRequestMatcher(http.MethodDelete, url, nil, http.StatusNoContent, nil)
+	client := getTestClient(requestMatcher)
+	err := client.DeleteSavedQuery(savedQueryId)
+	assert.Nil(t, err)
+}
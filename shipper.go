@@ -0,0 +1,457 @@
+package insight_goclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	SHIPPER_ADDRESS = "%s.data.logs.insight.rapid7.com:443"
+	// DEFAULT_SHIPPER_BUFFER_BYTES bounds the memory used to buffer lines while the shipper is (re)connecting
+	DEFAULT_SHIPPER_BUFFER_BYTES = 8 * 1024 * 1024
+	SHIPPER_DIAL_TIMEOUT         = 10 * time.Second
+	SHIPPER_WRITE_TIMEOUT        = 30 * time.Second
+	// SHIPPER_CLOSE_TIMEOUT is how long Close waits for the buffered lines to be flushed
+	SHIPPER_CLOSE_TIMEOUT = 30 * time.Second
+	SHIPPER_MIN_BACKOFF   = 500 * time.Millisecond
+	SHIPPER_MAX_BACKOFF   = 30 * time.Second
+	// SHIPPER_LINE_SEPARATOR replaces the newlines of multi-line events, which insight would otherwise split in
+	// several events; insight displays the unicode line separator as a newline
+	SHIPPER_LINE_SEPARATOR = "\u2028"
+	// SHIPPER_MAX_LINE_BYTES bounds the incomplete line kept by Write; longer lines are sent in several events
+	SHIPPER_MAX_LINE_BYTES = 64 * 1024
+)
+
+// ErrShipperClosed is returned when sending to a Shipper which has been closed
+var ErrShipperClosed = errors.New("Shipper is closed")
+
+// Shipper sends log events to insight over a persistent TLS connection, each line being prefixed with the token of
+// the Log it belongs to. Lines are buffered in memory while the connection is being (re)established; the oldest lines
+// are dropped when the buffer is full (see WithShipperBufferBytes). A Shipper is safe for concurrent use.
+type Shipper struct {
+	address     string
+	token       string
+	tlsConfig   *tls.Config
+	bufferBytes int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+
+	mutex       sync.Mutex
+	wakeUp      *sync.Cond
+	flushed     *sync.Cond
+	queue       []queuedLine
+	queuedBytes int
+	// enqueued is the sequence number of the last line enqueued and writing the one of the first line being written,
+	// zero when none is
+	enqueued uint64
+	writing  uint64
+	partial  []byte
+	dropped  uint64
+	closed   bool
+	aborted  bool
+	conn     net.Conn
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// queuedLine is a formatted line waiting to be written along with its sequence number, see Flush
+type queuedLine struct {
+	seq  uint64
+	data []byte
+}
+
+// ShipperOption configures a Shipper created via NewShipper
+type ShipperOption func(shipper *Shipper) error
+
+// WithShipperAddress overrides the host:port derived from the region, e.g: to ship to a local relay
+func WithShipperAddress(address string) ShipperOption {
+	return func(shipper *Shipper) error {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return fmt.Errorf("Invalid shipper address %s: %s", address, err)
+		}
+		shipper.address = address
+		return nil
+	}
+}
+
+// WithShipperTLSConfig sets the tls configuration used to connect, e.g: to trust a private certificate authority
+func WithShipperTLSConfig(tlsConfig *tls.Config) ShipperOption {
+	return func(shipper *Shipper) error {
+		if tlsConfig == nil {
+			return fmt.Errorf("tlsConfig input parameter is mandatory")
+		}
+		shipper.tlsConfig = tlsConfig.Clone()
+		return nil
+	}
+}
+
+// WithShipperBufferBytes bounds the memory used to buffer lines which have not been sent yet
+func WithShipperBufferBytes(bufferBytes int) ShipperOption {
+	return func(shipper *Shipper) error {
+		if bufferBytes <= 0 {
+			return fmt.Errorf("bufferBytes must be greater than zero, got %d", bufferBytes)
+		}
+		shipper.bufferBytes = bufferBytes
+		return nil
+	}
+}
+
+// WithShipperBackoff sets the exponential backoff applied between two connection attempts
+func WithShipperBackoff(minBackoff, maxBackoff time.Duration) ShipperOption {
+	return func(shipper *Shipper) error {
+		if minBackoff <= 0 || maxBackoff < minBackoff {
+			return fmt.Errorf("Invalid shipper backoff, 0 < minBackoff (%s) <= maxBackoff (%s) is expected", minBackoff, maxBackoff)
+		}
+		shipper.minBackoff = minBackoff
+		shipper.maxBackoff = maxBackoff
+		return nil
+	}
+}
+
+// NewShipper creates a Shipper sending lines to the Log owning token. The token may be left empty when every line is
+// sent with SendWithToken. The connection is established in the background, lines sent in the meantime are buffered.
+func NewShipper(region, token string, options ...ShipperOption) (*Shipper, error) {
+	shipper := &Shipper{
+		token:       token,
+		tlsConfig:   &tls.Config{},
+		bufferBytes: DEFAULT_SHIPPER_BUFFER_BYTES,
+		minBackoff:  SHIPPER_MIN_BACKOFF,
+		maxBackoff:  SHIPPER_MAX_BACKOFF,
+		done:        make(chan struct{}),
+	}
+	for _, option := range options {
+		if err := option(shipper); err != nil {
+			return nil, err
+		}
+	}
+	if shipper.address == "" {
+		if err := ValidateRegion(region); err != nil {
+			return nil, err
+		}
+		shipper.address = fmt.Sprintf(SHIPPER_ADDRESS, region)
+	}
+	shipper.wakeUp = sync.NewCond(&shipper.mutex)
+	shipper.flushed = sync.NewCond(&shipper.mutex)
+	ctx, cancel := context.WithCancel(context.Background())
+	shipper.cancel = cancel
+	go shipper.run(ctx)
+	return shipper, nil
+}
+
+// Write implements io.Writer: every complete line written is sent as an event of the shipper's Log. An incomplete
+// trailing line is kept until its newline is written or the shipper is closed, unless it reaches
+// SHIPPER_MAX_LINE_BYTES in which case it is sent in parts.
+func (shipper *Shipper) Write(p []byte) (int, error) {
+	if shipper.token == "" {
+		return 0, fmt.Errorf("Shipper has no token, use SendWithToken instead")
+	}
+	shipper.mutex.Lock()
+	defer shipper.mutex.Unlock()
+	if shipper.closed {
+		return 0, ErrShipperClosed
+	}
+	data := append(shipper.partial, p...)
+	for {
+		index := bytes.IndexByte(data, '\n')
+		if index < 0 {
+			break
+		}
+		shipper.enqueue(shipper.token, string(bytes.TrimSuffix(data[:index], []byte("\r"))))
+		data = data[index+1:]
+	}
+	for len(data) >= SHIPPER_MAX_LINE_BYTES {
+		// Split on a rune boundary, unless the line is not valid utf-8 anyway
+		split := SHIPPER_MAX_LINE_BYTES
+		for i := split; i < len(data) && i > SHIPPER_MAX_LINE_BYTES-utf8.UTFMax; i-- {
+			if utf8.RuneStart(data[i]) {
+				split = i
+				break
+			}
+		}
+		shipper.enqueue(shipper.token, string(data[:split]))
+		data = data[split:]
+	}
+	shipper.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
+
+// Send sends line as an event of the shipper's Log. Newlines within line are kept as part of the same event.
+func (shipper *Shipper) Send(line string) error {
+	if shipper.token == "" {
+		return fmt.Errorf("Shipper has no token, use SendWithToken instead")
+	}
+	return shipper.SendWithToken(shipper.token, line)
+}
+
+// SendWithToken sends line as an event of the Log owning token. Newlines within line are kept as part of the same
+// event.
+func (shipper *Shipper) SendWithToken(token, line string) error {
+	if token == "" {
+		return fmt.Errorf("token input parameter is mandatory")
+	}
+	shipper.mutex.Lock()
+	defer shipper.mutex.Unlock()
+	if shipper.closed {
+		return ErrShipperClosed
+	}
+	shipper.enqueue(token, strings.TrimRight(line, "\r\n"))
+	return nil
+}
+
+// Flush waits until the lines sent before the call have been written to the connection, i.e. they can no longer be
+// dropped, which makes senders wait while insight cannot be reached. Lines sent during the call are not waited for.
+// It fails when ctx is done first, when the shipper has been closed without flushing them or when lines were dropped
+// meanwhile, as they may be among them.
+func (shipper *Shipper) Flush(ctx context.Context) error {
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			shipper.mutex.Lock()
+			shipper.flushed.Broadcast()
+			shipper.mutex.Unlock()
+		case <-stop:
+		}
+	}()
+	shipper.mutex.Lock()
+	defer shipper.mutex.Unlock()
+	target, dropped := shipper.enqueued, shipper.dropped
+	for {
+		if shipper.aborted {
+			return ErrShipperClosed
+		}
+		if shipper.pending() > target {
+			if shipper.dropped > dropped {
+				return fmt.Errorf("Shipper dropped %d lines while flushing", shipper.dropped-dropped)
+			}
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		shipper.flushed.Wait()
+	}
+}
+
+// Dropped returns the number of lines dropped because the buffer was full or the shipper could not flush them
+func (shipper *Shipper) Dropped() uint64 {
+	shipper.mutex.Lock()
+	defer shipper.mutex.Unlock()
+	return shipper.dropped
+}
+
+// Close flushes the buffered lines, waiting up to SHIPPER_CLOSE_TIMEOUT, and closes the connection
+func (shipper *Shipper) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), SHIPPER_CLOSE_TIMEOUT)
+	defer cancel()
+	return shipper.CloseContext(ctx)
+}
+
+// CloseContext flushes the buffered lines and closes the connection. Lines which could not be flushed before ctx is
+// done are dropped and an error is returned.
+func (shipper *Shipper) CloseContext(ctx context.Context) error {
+	shipper.mutex.Lock()
+	if !shipper.closed {
+		if len(shipper.partial) > 0 {
+			shipper.enqueue(shipper.token, string(shipper.partial))
+			shipper.partial = nil
+		}
+		shipper.closed = true
+		shipper.wakeUp.Broadcast()
+	}
+	shipper.mutex.Unlock()
+
+	select {
+	case <-shipper.done:
+		return nil
+	case <-ctx.Done():
+	}
+	shipper.mutex.Lock()
+	shipper.aborted = true
+	if shipper.conn != nil {
+		shipper.conn.Close()
+	}
+	shipper.wakeUp.Broadcast()
+	shipper.flushed.Broadcast()
+	shipper.mutex.Unlock()
+	shipper.cancel()
+	<-shipper.done
+
+	shipper.mutex.Lock()
+	defer shipper.mutex.Unlock()
+	dropped := len(shipper.queue)
+	shipper.dropped += uint64(dropped)
+	shipper.queue = nil
+	shipper.queuedBytes = 0
+	if dropped > 0 {
+		return fmt.Errorf("Shipper closed before flushing %d lines: %s", dropped, ctx.Err())
+	}
+	return nil
+}
+
+// enqueue adds a line to the buffer, dropping the oldest lines when it is full. The mutex must be held.
+func (shipper *Shipper) enqueue(token, line string) {
+	formatted := []byte(fmt.Sprintf("%s %s\n", token, strings.Replace(line, "\n", SHIPPER_LINE_SEPARATOR, -1)))
+	shipper.enqueued++
+	shipper.pushBack(queuedLine{shipper.enqueued, formatted})
+	shipper.wakeUp.Signal()
+}
+
+func (shipper *Shipper) pushBack(line queuedLine) {
+	shipper.queue = append(shipper.queue, line)
+	shipper.queuedBytes += len(line.data)
+	shipper.trim()
+}
+
+// pushFront puts back lines which could not be written, keeping them ahead of the lines queued in the meantime
+func (shipper *Shipper) pushFront(lines []queuedLine) {
+	for _, line := range lines {
+		shipper.queuedBytes += len(line.data)
+	}
+	shipper.queue = append(append([]queuedLine{}, lines...), shipper.queue...)
+	shipper.trim()
+}
+
+// pending returns the sequence number of the oldest line which has been neither written nor dropped, the one of the
+// next line when there is none. The mutex must be held.
+func (shipper *Shipper) pending() uint64 {
+	if shipper.writing != 0 {
+		return shipper.writing
+	}
+	if len(shipper.queue) > 0 {
+		return shipper.queue[0].seq
+	}
+	return shipper.enqueued + 1
+}
+
+// trim drops lines until the buffer fits in bufferBytes, the oldest first. A single line larger than the buffer is
+// kept when it is the only one so that oversized events still have a chance to be sent.
+func (shipper *Shipper) trim() {
+	for shipper.queuedBytes > shipper.bufferBytes && len(shipper.queue) > 1 {
+		shipper.queuedBytes -= len(shipper.queue[0].data)
+		shipper.queue = shipper.queue[1:]
+		shipper.dropped++
+		shipper.flushed.Broadcast()
+	}
+}
+
+// next waits for buffered lines and takes them all, returning false once the shipper is closed and flushed or aborted
+func (shipper *Shipper) next() ([]queuedLine, bool) {
+	shipper.mutex.Lock()
+	defer shipper.mutex.Unlock()
+	for len(shipper.queue) == 0 && !shipper.closed && !shipper.aborted {
+		shipper.wakeUp.Wait()
+	}
+	if shipper.aborted || len(shipper.queue) == 0 {
+		return nil, false
+	}
+	lines := shipper.queue
+	shipper.queue = nil
+	shipper.queuedBytes = 0
+	shipper.writing = lines[0].seq
+	return lines, true
+}
+
+// written marks the end of a write, putting back the lines which could not be written
+func (shipper *Shipper) written(unwritten []queuedLine) {
+	shipper.mutex.Lock()
+	defer shipper.mutex.Unlock()
+	shipper.writing = 0
+	shipper.pushFront(unwritten)
+	shipper.flushed.Broadcast()
+}
+
+func (shipper *Shipper) run(ctx context.Context) {
+	defer close(shipper.done)
+	defer shipper.disconnect()
+	failures := 0
+	for {
+		lines, ok := shipper.next()
+		if !ok {
+			return
+		}
+		written, err := shipper.write(ctx, lines)
+		if err == nil {
+			shipper.written(nil)
+			failures = 0
+			continue
+		}
+		shipper.disconnect()
+		shipper.written(lines[written:])
+		failures++
+		if sleepContext(ctx, exponentialBackoff(shipper.minBackoff, failures-1, shipper.maxBackoff)) != nil {
+			return
+		}
+	}
+}
+
+// write sends the lines over the connection, dialing it first if needed, and returns how many were written
+func (shipper *Shipper) write(ctx context.Context, lines []queuedLine) (int, error) {
+	conn, err := shipper.connect(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i, line := range lines {
+		if err := conn.SetWriteDeadline(time.Now().Add(SHIPPER_WRITE_TIMEOUT)); err != nil {
+			return i, err
+		}
+		if _, err := conn.Write(line.data); err != nil {
+			return i, err
+		}
+	}
+	return len(lines), nil
+}
+
+func (shipper *Shipper) connect(ctx context.Context) (net.Conn, error) {
+	shipper.mutex.Lock()
+	conn := shipper.conn
+	shipper.mutex.Unlock()
+	if conn != nil {
+		return conn, nil
+	}
+	dialer := &net.Dialer{Timeout: SHIPPER_DIAL_TIMEOUT}
+	rawConn, err := dialer.DialContext(ctx, "tcp", shipper.address)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := shipper.tlsConfig.Clone()
+	if tlsConfig.ServerName == "" {
+		host, _, _ := net.SplitHostPort(shipper.address)
+		tlsConfig.ServerName = host
+	}
+	tlsConn := tls.Client(rawConn, tlsConfig)
+	if err := tlsConn.SetDeadline(time.Now().Add(SHIPPER_DIAL_TIMEOUT)); err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	shipper.mutex.Lock()
+	defer shipper.mutex.Unlock()
+	if shipper.aborted {
+		tlsConn.Close()
+		return nil, ErrShipperClosed
+	}
+	shipper.conn = tlsConn
+	return tlsConn, nil
+}
+
+func (shipper *Shipper) disconnect() {
+	shipper.mutex.Lock()
+	defer shipper.mutex.Unlock()
+	if shipper.conn != nil {
+		shipper.conn.Close()
+		shipper.conn = nil
+	}
+}
@@ -0,0 +1,233 @@
+package insight_goclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newShipperTestTLSConfigs returns the server and client tls configurations of a local TLS listener
+func newShipperTestTLSConfigs() (*tls.Config, *tls.Config) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	serverConfig := &tls.Config{Certificates: server.TLS.Certificates}
+	clientConfig := server.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+	clientConfig.ServerName = "example.com"
+	return serverConfig, clientConfig
+}
+
+// listenShipperTest accepts TLS connections on address and sends every line received on the returned channel
+func listenShipperTest(t *testing.T, address string, serverConfig *tls.Config) (net.Listener, <-chan string) {
+	listener, err := tls.Listen("tcp", address, serverConfig)
+	assert.Nil(t, err)
+	lines := make(chan string, 100)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					lines <- scanner.Text()
+				}
+			}()
+		}
+	}()
+	return listener, lines
+}
+
+func receiveShipperTestLines(t *testing.T, lines <-chan string, count int) []string {
+	var received []string
+	for len(received) < count {
+		select {
+		case line := <-lines:
+			received = append(received, line)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected %d lines, received %v", count, received)
+		}
+	}
+	return received
+}
+
+func TestShipper_NewShipper(t *testing.T) {
+	_, err := NewShipper("mars", "log-token")
+	assert.NotNil(t, err)
+	_, err = NewShipper("eu", "log-token", WithShipperAddress("no-port"))
+	assert.NotNil(t, err)
+	_, err = NewShipper("eu", "log-token", WithShipperBufferBytes(0))
+	assert.NotNil(t, err)
+}
+
+func TestShipper_WriteAndSend(t *testing.T) {
+	serverConfig, clientConfig := newShipperTestTLSConfigs()
+	listener, lines := listenShipperTest(t, "127.0.0.1:0", serverConfig)
+	defer listener.Close()
+
+	shipper, err := NewShipper("", "log-token", WithShipperAddress(listener.Addr().String()), WithShipperTLSConfig(clientConfig))
+	assert.Nil(t, err)
+
+	n, err := shipper.Write([]byte("first\nsecond\r\nincom"))
+	assert.Nil(t, err)
+	assert.Equal(t, 19, n)
+	assert.Nil(t, shipper.Send("multi\nline"))
+	assert.Nil(t, shipper.SendWithToken("other-token", "routed"))
+	assert.Nil(t, shipper.Close())
+
+	assert.Equal(t, []string{
+		"log-token first",
+		"log-token second",
+		"log-token multi line",
+		"other-token routed",
+		"log-token incom",
+	}, receiveShipperTestLines(t, lines, 5))
+
+	assert.Equal(t, ErrShipperClosed, shipper.Send("late"))
+	_, err = shipper.Write([]byte("late\n"))
+	assert.Equal(t, ErrShipperClosed, err)
+}
+
+func TestShipper_WriteBoundsIncompleteLines(t *testing.T) {
+	shipper, err := NewShipper("", "log-token", WithShipperAddress("127.0.0.1:1"),
+		WithShipperBackoff(time.Second, time.Second))
+	assert.Nil(t, err)
+
+	_, err = shipper.Write(make([]byte, 2*SHIPPER_MAX_LINE_BYTES+10))
+	assert.Nil(t, err)
+	shipper.mutex.Lock()
+	assert.Len(t, shipper.partial, 10)
+	shipper.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.NotNil(t, shipper.CloseContext(ctx))
+	assert.Equal(t, uint64(3), shipper.Dropped())
+}
+
+func TestShipper_WriteSplitsLongLinesOnRuneBoundaries(t *testing.T) {
+	shipper, err := NewShipper("", "log-token", WithShipperAddress("127.0.0.1:1"),
+		WithShipperBackoff(time.Second, time.Second))
+	assert.Nil(t, err)
+
+	_, err = shipper.Write(append(bytes.Repeat([]byte("a"), SHIPPER_MAX_LINE_BYTES-1), "éb"...))
+	assert.Nil(t, err)
+	shipper.mutex.Lock()
+	assert.Equal(t, "éb", string(shipper.partial))
+	shipper.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.NotNil(t, shipper.CloseContext(ctx))
+	assert.Equal(t, uint64(2), shipper.Dropped())
+}
+
+func TestShipper_BuffersUntilConnected(t *testing.T) {
+	serverConfig, clientConfig := newShipperTestTLSConfigs()
+	reserved, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	address := reserved.Addr().String()
+	reserved.Close()
+
+	shipper, err := NewShipper("", "log-token", WithShipperAddress(address), WithShipperTLSConfig(clientConfig),
+		WithShipperBackoff(10*time.Millisecond, 50*time.Millisecond))
+	assert.Nil(t, err)
+	assert.Nil(t, shipper.Send("buffered"))
+	time.Sleep(50 * time.Millisecond)
+
+	listener, lines := listenShipperTest(t, address, serverConfig)
+	defer listener.Close()
+	assert.Equal(t, []string{"log-token buffered"}, receiveShipperTestLines(t, lines, 1))
+	assert.Nil(t, shipper.Close())
+	assert.Equal(t, uint64(0), shipper.Dropped())
+}
+
+func TestShipper_Flush(t *testing.T) {
+	serverConfig, clientConfig := newShipperTestTLSConfigs()
+	reserved, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	address := reserved.Addr().String()
+	reserved.Close()
+
+	shipper, err := NewShipper("", "log-token", WithShipperAddress(address), WithShipperTLSConfig(clientConfig),
+		WithShipperBackoff(10*time.Millisecond, 50*time.Millisecond))
+	assert.Nil(t, err)
+	assert.Nil(t, shipper.Send("buffered"))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, shipper.Flush(ctx))
+
+	listener, lines := listenShipperTest(t, address, serverConfig)
+	defer listener.Close()
+	assert.Nil(t, shipper.Flush(context.Background()))
+	assert.Equal(t, []string{"log-token buffered"}, receiveShipperTestLines(t, lines, 1))
+	assert.Nil(t, shipper.Close())
+	assert.Nil(t, shipper.Flush(context.Background()))
+}
+
+func TestShipper_FlushUnderSteadyLoad(t *testing.T) {
+	serverConfig, clientConfig := newShipperTestTLSConfigs()
+	listener, lines := listenShipperTest(t, "127.0.0.1:0", serverConfig)
+	defer listener.Close()
+	go func() {
+		for range lines {
+		}
+	}()
+
+	shipper, err := NewShipper("", "log-token", WithShipperAddress(listener.Addr().String()), WithShipperTLSConfig(clientConfig))
+	assert.Nil(t, err)
+	stop := make(chan struct{})
+	senders := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		go func() {
+			defer func() { senders <- struct{}{} }()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					shipper.Send("steady")
+				}
+			}
+		}()
+	}
+
+	assert.Nil(t, shipper.Send("flushed"))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.Nil(t, shipper.Flush(ctx))
+	close(stop)
+	for i := 0; i < 4; i++ {
+		<-senders
+	}
+	assert.Nil(t, shipper.Close())
+}
+
+func TestShipper_BufferIsBounded(t *testing.T) {
+	_, clientConfig := newShipperTestTLSConfigs()
+	reserved, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	address := reserved.Addr().String()
+	reserved.Close()
+
+	shipper, err := NewShipper("", "log-token", WithShipperAddress(address), WithShipperTLSConfig(clientConfig),
+		WithShipperBufferBytes(64), WithShipperBackoff(10*time.Millisecond, 10*time.Millisecond))
+	assert.Nil(t, err)
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, shipper.Send("0123456789"))
+	}
+	assert.True(t, shipper.Dropped() > 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.NotNil(t, shipper.CloseContext(ctx))
+	assert.Equal(t, uint64(10), shipper.Dropped())
+}
@@ -0,0 +1,574 @@
+package insight_goclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SPOOL_SEGMENT_EXTENSION = ".seg"
+	SPOOL_CURSOR_FILE       = "cursor"
+	// SPOOL_RECORD_HEADER_BYTES is the size of the header of every record: its length and crc32, both big endian
+	SPOOL_RECORD_HEADER_BYTES = 8
+	// SPOOL_MAX_RECORD_BYTES bounds the size of a record, which is drained as a single event by DrainSpool
+	SPOOL_MAX_RECORD_BYTES      = DEFAULT_INGESTION_MAX_BATCH_BYTES
+	DEFAULT_SPOOL_MAX_BYTES     = 256 * 1024 * 1024
+	DEFAULT_SPOOL_SEGMENT_BYTES = 8 * 1024 * 1024
+	// SPOOL_DRAIN_BATCH_RECORDS is the number of records handed to the ingestion client at once when draining
+	SPOOL_DRAIN_BATCH_RECORDS = 1000
+)
+
+// ErrSpoolClosed is returned when using a Spool which has been closed
+var ErrSpoolClosed = errors.New("Spool is closed")
+
+// Spool is a durable, size capped, on-disk queue of outgoing log events. Records are appended to segment files of the
+// spool directory, each record being prefixed by its length and crc32 so that a record torn by a crash is detected
+// and discarded when the spool is opened again. The position of the oldest record not drained yet is kept in a cursor
+// file, hence records are delivered at least once. When the spool is full, the oldest segments are evicted. A Spool
+// is safe for concurrent use.
+type Spool struct {
+	dir          string
+	maxBytes     int64
+	segmentBytes int64
+	syncInterval time.Duration
+
+	mutex    sync.Mutex
+	segments []*spoolSegment
+	active   *os.File
+	lastSync time.Time
+	// readOffset and consumed locate the oldest record not drained yet within the oldest segment
+	readOffset int64
+	consumed   int
+	evicted    uint64
+	closed     bool
+}
+
+type spoolSegment struct {
+	id      uint64
+	size    int64
+	records int
+}
+
+// SpoolOption configures a Spool opened via OpenSpool
+type SpoolOption func(spool *Spool) error
+
+// WithSpoolMaxBytes caps the disk space used by the spool, the oldest records being evicted beyond it
+func WithSpoolMaxBytes(maxBytes int64) SpoolOption {
+	return func(spool *Spool) error {
+		if maxBytes <= 0 {
+			return fmt.Errorf("maxBytes must be greater than zero, got %d", maxBytes)
+		}
+		spool.maxBytes = maxBytes
+		return nil
+	}
+}
+
+// WithSpoolSegmentBytes sets the size from which a new segment file is started. Eviction drops whole segments
+func WithSpoolSegmentBytes(segmentBytes int64) SpoolOption {
+	return func(spool *Spool) error {
+		if segmentBytes <= 0 {
+			return fmt.Errorf("segmentBytes must be greater than zero, got %d", segmentBytes)
+		}
+		spool.segmentBytes = segmentBytes
+		return nil
+	}
+}
+
+// WithSpoolSyncInterval sets how often appended records are flushed to stable storage. Zero, the default, syncs every
+// record; a positive interval syncs at most once per interval, trading the last records on a power loss for
+// throughput; a negative interval leaves it to the operating system.
+func WithSpoolSyncInterval(syncInterval time.Duration) SpoolOption {
+	return func(spool *Spool) error {
+		spool.syncInterval = syncInterval
+		return nil
+	}
+}
+
+// OpenSpool opens the spool stored in dir, creating it if needed. Records left by a previous run are replayed: they
+// are drained before the ones appended afterwards.
+func OpenSpool(dir string, options ...SpoolOption) (*Spool, error) {
+	spool := &Spool{
+		dir:          dir,
+		maxBytes:     DEFAULT_SPOOL_MAX_BYTES,
+		segmentBytes: DEFAULT_SPOOL_SEGMENT_BYTES,
+	}
+	for _, option := range options {
+		if err := option(spool); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if err := spool.load(); err != nil {
+		return nil, err
+	}
+	return spool, nil
+}
+
+// Append adds a record to the spool, evicting the oldest segments if the spool is full. Records holding a newline are
+// rejected as they would be ingested as several events.
+func (spool *Spool) Append(record []byte) error {
+	recordBytes := int64(SPOOL_RECORD_HEADER_BYTES + len(record))
+	if len(record) > SPOOL_MAX_RECORD_BYTES || recordBytes > spool.maxBytes {
+		return fmt.Errorf("Record of %d bytes exceeds the capacity of the spool", len(record))
+	}
+	if bytes.ContainsAny(record, "\r\n") {
+		return fmt.Errorf("Record holds a newline, it would be ingested as several events")
+	}
+	spool.mutex.Lock()
+	defer spool.mutex.Unlock()
+	if spool.closed {
+		return ErrSpoolClosed
+	}
+	active := spool.segments[len(spool.segments)-1]
+	if active.size > 0 && (active.size+recordBytes > spool.segmentBytes || spool.size()+recordBytes > spool.maxBytes) {
+		if err := spool.roll(); err != nil {
+			return err
+		}
+		active = spool.segments[len(spool.segments)-1]
+	}
+	for spool.size()+recordBytes > spool.maxBytes && len(spool.segments) > 1 {
+		if err := spool.evictOldest(); err != nil {
+			return err
+		}
+	}
+	buffer := make([]byte, recordBytes)
+	binary.BigEndian.PutUint32(buffer[0:4], uint32(len(record)))
+	binary.BigEndian.PutUint32(buffer[4:8], crc32.ChecksumIEEE(record))
+	copy(buffer[SPOOL_RECORD_HEADER_BYTES:], record)
+	if _, err := spool.active.Write(buffer); err != nil {
+		// Drop whatever part of the record made it to the file so that the segment stays readable
+		spool.active.Truncate(active.size)
+		return err
+	}
+	active.size += recordBytes
+	active.records++
+	if spool.syncInterval == 0 || (spool.syncInterval > 0 && time.Since(spool.lastSync) >= spool.syncInterval) {
+		spool.lastSync = time.Now()
+		return spool.active.Sync()
+	}
+	return nil
+}
+
+// Len returns the number of records which have not been drained yet
+func (spool *Spool) Len() int {
+	spool.mutex.Lock()
+	defer spool.mutex.Unlock()
+	length := -spool.consumed
+	for _, segment := range spool.segments {
+		length += segment.records
+	}
+	return length
+}
+
+// Evicted returns the number of records dropped, without being drained, because the spool was full
+func (spool *Spool) Evicted() uint64 {
+	spool.mutex.Lock()
+	defer spool.mutex.Unlock()
+	return spool.evicted
+}
+
+// Drain calls fn with the oldest records, at most maxRecords at a time, until the spool is empty. Records are removed
+// from the spool once fn returns without error; draining stops at the first error, which is returned, leaving the
+// records passed to fn in the spool.
+func (spool *Spool) Drain(maxRecords int, fn func(records [][]byte) error) error {
+	return spool.DrainContext(context.Background(), maxRecords, fn)
+}
+
+// DrainContext calls fn with the oldest records, at most maxRecords at a time, until the spool is empty or ctx is done.
+// Records are removed from the spool once fn returns without error; draining stops at the first error, which is
+// returned, leaving the records passed to fn in the spool.
+func (spool *Spool) DrainContext(ctx context.Context, maxRecords int, fn func(records [][]byte) error) error {
+	if maxRecords <= 0 {
+		return fmt.Errorf("maxRecords must be greater than zero, got %d", maxRecords)
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		records, end, err := spool.read(maxRecords)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		if err := fn(records); err != nil {
+			return err
+		}
+		if err := spool.commit(end); err != nil {
+			return err
+		}
+	}
+}
+
+// Close syncs and closes the spool. Records which have not been drained are kept for the next OpenSpool
+func (spool *Spool) Close() error {
+	spool.mutex.Lock()
+	defer spool.mutex.Unlock()
+	if spool.closed {
+		return nil
+	}
+	spool.closed = true
+	if err := spool.active.Sync(); err != nil {
+		spool.active.Close()
+		return err
+	}
+	return spool.active.Close()
+}
+
+// DrainSpool sends the records of the spool as plain text events until the spool is empty
+func (client *IngestionClient) DrainSpool(spool *Spool) error {
+	return client.DrainSpoolContext(context.Background(), spool)
+}
+
+// DrainSpoolContext sends the records of the spool as plain text events until the spool is empty using the provided
+// context. Records are only removed from the spool once they have been accepted by insight. Records larger than the
+// MaxBatchBytes of the client can never be sent: they are dropped, and reported by an error once the spool is drained.
+func (client *IngestionClient) DrainSpoolContext(ctx context.Context, spool *Spool) error {
+	maxBatchBytes := client.maxBatchBytes()
+	dropped := 0
+	err := spool.DrainContext(ctx, SPOOL_DRAIN_BATCH_RECORDS, func(records [][]byte) error {
+		lines := make([][]byte, 0, len(records))
+		for _, record := range records {
+			if len(record) > maxBatchBytes {
+				dropped++
+				continue
+			}
+			lines = append(lines, record)
+		}
+		return client.sendLines(ctx, lines, "text/plain")
+	})
+	if err == nil && dropped > 0 {
+		err = fmt.Errorf("Dropped %d spooled records exceeding the maximum batch size of %d bytes", dropped, maxBatchBytes)
+	}
+	return err
+}
+
+// spoolPosition locates a record within the spool; records is the number of records preceding it in its segment
+type spoolPosition struct {
+	segmentId uint64
+	offset    int64
+	records   int
+}
+
+// read returns up to maxRecords records starting at the read position, along with the position following them
+func (spool *Spool) read(maxRecords int) ([][]byte, spoolPosition, error) {
+	spool.mutex.Lock()
+	defer spool.mutex.Unlock()
+	if spool.closed {
+		return nil, spoolPosition{}, ErrSpoolClosed
+	}
+	var records [][]byte
+	position := spoolPosition{spool.segments[0].id, spool.readOffset, spool.consumed}
+	for _, segment := range spool.segments {
+		if len(records) == maxRecords {
+			break
+		}
+		offset, consumed := int64(0), 0
+		if segment.id == position.segmentId {
+			offset, consumed = position.offset, position.records
+		}
+		segmentRecords, end, err := spool.readSegment(segment, offset, maxRecords-len(records))
+		if err != nil {
+			return nil, spoolPosition{}, err
+		}
+		records = append(records, segmentRecords...)
+		position = spoolPosition{segment.id, end, consumed + len(segmentRecords)}
+	}
+	return records, position, nil
+}
+
+func (spool *Spool) readSegment(segment *spoolSegment, offset int64, maxRecords int) ([][]byte, int64, error) {
+	file, err := os.Open(spool.segmentPath(segment.id))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, 0, err
+	}
+	var records [][]byte
+	for len(records) < maxRecords && offset < segment.size {
+		record, err := readSpoolRecord(file)
+		if err != nil {
+			return nil, 0, fmt.Errorf("Spool segment %s is corrupted at offset %d: %s", spool.segmentPath(segment.id), offset, err)
+		}
+		records = append(records, record)
+		offset += int64(SPOOL_RECORD_HEADER_BYTES + len(record))
+	}
+	return records, offset, nil
+}
+
+// commit removes the drained records up to end from the spool. Segments evicted while the records were being
+// drained are already gone, in which case there is nothing left to remove.
+func (spool *Spool) commit(end spoolPosition) error {
+	spool.mutex.Lock()
+	defer spool.mutex.Unlock()
+	if spool.closed {
+		return ErrSpoolClosed
+	}
+	if spool.segments[0].id > end.segmentId {
+		return nil
+	}
+	for spool.segments[0].id < end.segmentId {
+		if err := spool.removeOldest(); err != nil {
+			return err
+		}
+	}
+	if end.offset > spool.readOffset {
+		spool.readOffset = end.offset
+		spool.consumed = end.records
+	}
+	oldest := spool.segments[0]
+	if spool.readOffset >= oldest.size && oldest.size > 0 {
+		if len(spool.segments) == 1 {
+			if err := spool.roll(); err != nil {
+				return err
+			}
+		}
+		if err := spool.removeOldest(); err != nil {
+			return err
+		}
+	}
+	return spool.writeCursor()
+}
+
+// roll closes the active segment and starts a new one. The mutex must be held.
+func (spool *Spool) roll() error {
+	if err := spool.active.Sync(); err != nil {
+		return err
+	}
+	if err := spool.active.Close(); err != nil {
+		return err
+	}
+	return spool.createSegment(spool.segments[len(spool.segments)-1].id + 1)
+}
+
+// createSegment creates a new empty segment and makes it the active one. The mutex must be held.
+func (spool *Spool) createSegment(id uint64) error {
+	if err := spool.openActive(id); err != nil {
+		return err
+	}
+	if err := syncDir(spool.dir); err != nil {
+		spool.active.Close()
+		return err
+	}
+	spool.segments = append(spool.segments, &spoolSegment{id: id})
+	return nil
+}
+
+func (spool *Spool) openActive(id uint64) error {
+	file, err := os.OpenFile(spool.segmentPath(id), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	spool.active = file
+	return nil
+}
+
+// evictOldest drops the oldest segment, counting its records which have not been drained. The mutex must be held.
+func (spool *Spool) evictOldest() error {
+	spool.evicted += uint64(spool.segments[0].records - spool.consumed)
+	if err := spool.removeOldest(); err != nil {
+		return err
+	}
+	return spool.writeCursor()
+}
+
+// removeOldest deletes the oldest segment, which must not be the active one, moving the read position to the next
+// segment. The mutex must be held.
+func (spool *Spool) removeOldest() error {
+	if err := os.Remove(spool.segmentPath(spool.segments[0].id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	spool.segments = spool.segments[1:]
+	spool.readOffset = 0
+	spool.consumed = 0
+	return nil
+}
+
+func (spool *Spool) size() int64 {
+	var size int64
+	for _, segment := range spool.segments {
+		size += segment.size
+	}
+	return size
+}
+
+// load scans the segments left in the spool directory, truncating records torn by a crash, and restores the cursor
+func (spool *Spool) load() error {
+	entries, err := ioutil.ReadDir(spool.dir)
+	if err != nil {
+		return err
+	}
+	var ids []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, SPOOL_SEGMENT_EXTENSION) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, SPOOL_SEGMENT_EXTENSION), 16, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	cursor, err := spool.readCursor()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if id < cursor.segmentId {
+			// Drained before the previous run stopped, but not removed yet
+			if err := os.Remove(spool.segmentPath(id)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		segment, boundaries, err := spool.scanSegment(id)
+		if err != nil {
+			return err
+		}
+		if len(spool.segments) == 0 && id == cursor.segmentId {
+			for _, boundary := range boundaries {
+				if boundary > cursor.offset {
+					break
+				}
+				spool.readOffset = boundary
+				spool.consumed++
+			}
+		}
+		spool.segments = append(spool.segments, segment)
+	}
+	if len(spool.segments) == 0 {
+		return spool.createSegment(cursor.segmentId + 1)
+	}
+	return spool.openActive(spool.segments[len(spool.segments)-1].id)
+}
+
+// scanSegment validates every record of a segment, truncating it at the first torn or corrupted record. It returns
+// the offset following each valid record.
+func (spool *Spool) scanSegment(id uint64) (*spoolSegment, []int64, error) {
+	path := spool.segmentPath(id)
+	file, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	segment := &spoolSegment{id: id}
+	var boundaries []int64
+	for {
+		record, err := readSpoolRecord(file)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if err := file.Truncate(segment.size); err != nil {
+				return nil, nil, err
+			}
+			if err := file.Sync(); err != nil {
+				return nil, nil, err
+			}
+			break
+		}
+		segment.size += int64(SPOOL_RECORD_HEADER_BYTES + len(record))
+		segment.records++
+		boundaries = append(boundaries, segment.size)
+	}
+	return segment, boundaries, nil
+}
+
+// readSpoolRecord reads the next record, returning io.EOF when there is none and an error when it is torn or corrupted
+func readSpoolRecord(reader io.Reader) ([]byte, error) {
+	header := make([]byte, SPOOL_RECORD_HEADER_BYTES)
+	if n, err := io.ReadFull(reader, header); err != nil {
+		if err == io.EOF && n == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("torn record header")
+	}
+	length := binary.BigEndian.Uint32(header[0:4])
+	if length > SPOOL_MAX_RECORD_BYTES {
+		return nil, fmt.Errorf("invalid record length %d", length)
+	}
+	record := make([]byte, length)
+	if _, err := io.ReadFull(reader, record); err != nil {
+		return nil, fmt.Errorf("torn record")
+	}
+	if crc32.ChecksumIEEE(record) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, fmt.Errorf("record checksum mismatch")
+	}
+	return record, nil
+}
+
+func (spool *Spool) readCursor() (spoolPosition, error) {
+	content, err := ioutil.ReadFile(filepath.Join(spool.dir, SPOOL_CURSOR_FILE))
+	if os.IsNotExist(err) {
+		return spoolPosition{}, nil
+	}
+	if err != nil {
+		return spoolPosition{}, err
+	}
+	var cursor spoolPosition
+	if _, err := fmt.Sscanf(string(content), "%d %d", &cursor.segmentId, &cursor.offset); err != nil {
+		// A corrupted cursor only means that drained records may be delivered again
+		return spoolPosition{}, nil
+	}
+	return cursor, nil
+}
+
+// writeCursor atomically persists the read position. The mutex must be held.
+func (spool *Spool) writeCursor() error {
+	path := filepath.Join(spool.dir, SPOOL_CURSOR_FILE)
+	temporaryPath := path + ".tmp"
+	file, err := os.OpenFile(temporaryPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(file, "%d %d\n", spool.segments[0].id, spool.readOffset); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(temporaryPath, path); err != nil {
+		return err
+	}
+	return syncDir(spool.dir)
+}
+
+func (spool *Spool) segmentPath(id uint64) string {
+	return filepath.Join(spool.dir, fmt.Sprintf("%016x%s", id, SPOOL_SEGMENT_EXTENSION))
+}
+
+// syncDir flushes the directory entries so that created, renamed or removed files survive a crash
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
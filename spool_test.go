@@ -0,0 +1,192 @@
+package insight_goclient
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newSpoolTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "insight-spool")
+	assert.Nil(t, err)
+	return dir
+}
+
+func drainSpoolTest(t *testing.T, spool *Spool) []string {
+	var drained []string
+	err := spool.Drain(2, func(records [][]byte) error {
+		for _, record := range records {
+			drained = append(drained, string(record))
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	return drained
+}
+
+func TestSpool_AppendAndDrain(t *testing.T) {
+	dir := newSpoolTestDir(t)
+	defer os.RemoveAll(dir)
+	spool, err := OpenSpool(dir, WithSpoolSegmentBytes(32))
+	assert.Nil(t, err)
+	defer spool.Close()
+
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, spool.Append([]byte(fmt.Sprintf("event %d", i))))
+	}
+	assert.Equal(t, 5, spool.Len())
+	assert.Equal(t, []string{"event 0", "event 1", "event 2", "event 3", "event 4"}, drainSpoolTest(t, spool))
+	assert.Equal(t, 0, spool.Len())
+
+	assert.Nil(t, spool.Append([]byte("event 5")))
+	assert.Equal(t, []string{"event 5"}, drainSpoolTest(t, spool))
+}
+
+func TestSpool_DrainErrorKeepsRecords(t *testing.T) {
+	dir := newSpoolTestDir(t)
+	defer os.RemoveAll(dir)
+	spool, err := OpenSpool(dir)
+	assert.Nil(t, err)
+	defer spool.Close()
+
+	assert.Nil(t, spool.Append([]byte("first")))
+	assert.Nil(t, spool.Append([]byte("second")))
+	err = spool.Drain(1, func(records [][]byte) error {
+		if string(records[0]) == "second" {
+			return fmt.Errorf("network is down")
+		}
+		return nil
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"second"}, drainSpoolTest(t, spool))
+}
+
+func TestSpool_ReplayOnOpen(t *testing.T) {
+	dir := newSpoolTestDir(t)
+	defer os.RemoveAll(dir)
+	spool, err := OpenSpool(dir, WithSpoolSegmentBytes(32))
+	assert.Nil(t, err)
+	for i := 0; i < 4; i++ {
+		assert.Nil(t, spool.Append([]byte(fmt.Sprintf("event %d", i))))
+	}
+	err = spool.Drain(3, func(records [][]byte) error {
+		return ErrStopIteration
+	})
+	assert.Equal(t, ErrStopIteration, err)
+	err = spool.Drain(1, func(records [][]byte) error {
+		if string(records[0]) == "event 1" {
+			return ErrStopIteration
+		}
+		return nil
+	})
+	assert.Equal(t, ErrStopIteration, err)
+	assert.Nil(t, spool.Close())
+
+	spool, err = OpenSpool(dir, WithSpoolSegmentBytes(32))
+	assert.Nil(t, err)
+	defer spool.Close()
+	assert.Equal(t, 3, spool.Len())
+	assert.Nil(t, spool.Append([]byte("event 4")))
+	assert.Equal(t, []string{"event 1", "event 2", "event 3", "event 4"}, drainSpoolTest(t, spool))
+}
+
+func TestSpool_TornRecordIsDiscardedOnOpen(t *testing.T) {
+	dir := newSpoolTestDir(t)
+	defer os.RemoveAll(dir)
+	spool, err := OpenSpool(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, spool.Append([]byte("complete")))
+	assert.Nil(t, spool.Close())
+
+	segments, err := filepath.Glob(filepath.Join(dir, "*"+SPOOL_SEGMENT_EXTENSION))
+	assert.Nil(t, err)
+	assert.Len(t, segments, 1)
+	file, err := os.OpenFile(segments[0], os.O_WRONLY|os.O_APPEND, 0600)
+	assert.Nil(t, err)
+	_, err = file.Write([]byte{0, 0, 0, 42, 1, 2, 3, 4, 't', 'o', 'r', 'n'})
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+
+	spool, err = OpenSpool(dir)
+	assert.Nil(t, err)
+	defer spool.Close()
+	assert.Equal(t, 1, spool.Len())
+	assert.Nil(t, spool.Append([]byte("after crash")))
+	assert.Equal(t, []string{"complete", "after crash"}, drainSpoolTest(t, spool))
+}
+
+func TestSpool_EvictsOldestWhenFull(t *testing.T) {
+	dir := newSpoolTestDir(t)
+	defer os.RemoveAll(dir)
+	// Every record takes 8 + 7 bytes, hence 2 records per segment and 2 segments at most
+	spool, err := OpenSpool(dir, WithSpoolSegmentBytes(30), WithSpoolMaxBytes(60))
+	assert.Nil(t, err)
+	defer spool.Close()
+
+	for i := 0; i < 6; i++ {
+		assert.Nil(t, spool.Append([]byte(fmt.Sprintf("event %d", i))))
+	}
+	assert.Equal(t, uint64(2), spool.Evicted())
+	assert.Equal(t, []string{"event 2", "event 3", "event 4", "event 5"}, drainSpoolTest(t, spool))
+
+	assert.NotNil(t, spool.Append(make([]byte, 60)))
+}
+
+func TestSpool_IngestionClientDrainsSpool(t *testing.T) {
+	dir := newSpoolTestDir(t)
+	defer os.RemoveAll(dir)
+	spool, err := OpenSpool(dir)
+	assert.Nil(t, err)
+	defer spool.Close()
+	assert.Nil(t, spool.Append([]byte("first")))
+	assert.Nil(t, spool.Append([]byte("second")))
+
+	server, requests := newIngestionTestServer(t)
+	defer server.Close()
+	client, err := NewIngestionClient("", "log-token", WithIngestionBaseUrl(server.URL))
+	assert.Nil(t, err)
+
+	assert.Nil(t, client.DrainSpool(spool))
+	assert.Equal(t, []ingestedRequest{{"/v1/noformat/log-token", "text/plain", "", "first\nsecond"}}, requests())
+	assert.Equal(t, 0, spool.Len())
+}
+
+func TestSpool_AppendRejectsRecordsWhichCannotBeIngested(t *testing.T) {
+	dir := newSpoolTestDir(t)
+	defer os.RemoveAll(dir)
+	spool, err := OpenSpool(dir)
+	assert.Nil(t, err)
+	defer spool.Close()
+
+	assert.NotNil(t, spool.Append(make([]byte, 2*1024*1024)))
+	assert.NotNil(t, spool.Append([]byte("first\nsecond")))
+	assert.NotNil(t, spool.Append([]byte("first\r")))
+	assert.Nil(t, spool.Append(make([]byte, SPOOL_MAX_RECORD_BYTES)))
+	assert.Equal(t, 1, spool.Len())
+}
+
+func TestSpool_DrainDropsRecordsLargerThanTheBatchSize(t *testing.T) {
+	dir := newSpoolTestDir(t)
+	defer os.RemoveAll(dir)
+	spool, err := OpenSpool(dir)
+	assert.Nil(t, err)
+	defer spool.Close()
+	assert.Nil(t, spool.Append([]byte("first")))
+	assert.Nil(t, spool.Append([]byte("too large to be sent")))
+	assert.Nil(t, spool.Append([]byte("second")))
+
+	server, requests := newIngestionTestServer(t)
+	defer server.Close()
+	client, err := NewIngestionClient("", "log-token", WithIngestionBaseUrl(server.URL), WithMaxBatchBytes(8))
+	assert.Nil(t, err)
+
+	assert.NotNil(t, client.DrainSpool(spool))
+	assert.Equal(t, []ingestedRequest{
+		{"/v1/noformat/log-token", "text/plain", "", "first"},
+		{"/v1/noformat/log-token", "text/plain", "", "second"},
+	}, requests())
+	assert.Equal(t, 0, spool.Len())
+}
@@ -1,6 +1,7 @@
 package insight_goclient
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -46,21 +47,54 @@ type TagRequest struct {
 
 // GetTags gets details of an existing Tag and Alert
 func (client *InsightClient) GetTags() ([]*Tag, error) {
-	var tags Tags
-	if err := client.get(TAGS_PATH, &tags); err != nil {
+	return client.GetTagsContext(context.Background())
+}
+
+// GetTagsContext gets details of a list of all Tags and Alerts using the provided context
+func (client *InsightClient) GetTagsContext(ctx context.Context) ([]*Tag, error) {
+	var result []*Tag
+	err := client.ForEachTagContext(ctx, func(tag *Tag) error {
+		result = append(result, tag)
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	return tags.Tags, nil
+	return result, nil
+}
+
+// ForEachTag calls fn for every Tag of the account, fetching them page by page
+func (client *InsightClient) ForEachTag(fn func(tag *Tag) error) error {
+	return client.ForEachTagContext(context.Background(), fn)
+}
+
+// ForEachTagContext calls fn for every Tag of the account, fetching them page by page using the provided
+// context. Iteration stops at the first error returned by fn; return ErrStopIteration to stop without failing
+func (client *InsightClient) ForEachTagContext(ctx context.Context, fn func(tag *Tag) error) error {
+	var tags Tags
+	return client.getPages(ctx, TAGS_PATH, &tags, func() error {
+		for _, tag := range tags.Tags {
+			if err := fn(tag); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // GetTag gets details of a list of all Tags and Alerts
 func (client *InsightClient) GetTag(tagId string) (*Tag, error) {
+	return client.GetTagContext(context.Background(), tagId)
+}
+
+// GetTagContext gets details of an existing Tag and Alert using the provided context
+func (client *InsightClient) GetTagContext(ctx context.Context, tagId string) (*Tag, error) {
 	var tagRequest TagRequest
 	endpoint, err := client.getTagEndpoint(tagId)
 	if err != nil {
 		return nil, err
 	}
-	if err := client.get(endpoint, &tagRequest); err != nil {
+	if err := client.getWithContext(ctx, endpoint, &tagRequest); err != nil {
 		return nil, err
 	}
 	return tagRequest.Tag, nil
@@ -68,11 +102,16 @@ func (client *InsightClient) GetTag(tagId string) (*Tag, error) {
 
 // PostTag creates a new Tag and Alert
 func (client *InsightClient) PostTag(tag *Tag) error {
+	return client.PostTagContext(context.Background(), tag)
+}
+
+// PostTagContext creates a new Tag and Alert using the provided context
+func (client *InsightClient) PostTagContext(ctx context.Context, tag *Tag) error {
 	if tag.UserData == nil {
 		tag.UserData = make(map[string]string)
 	}
 	tagRequest := TagRequest{tag}
-	resp, err := client.post(TAGS_PATH, tagRequest)
+	resp, err := client.postWithContext(ctx, TAGS_PATH, tagRequest)
 	if err != nil {
 		return err
 	}
@@ -85,6 +124,11 @@ func (client *InsightClient) PostTag(tag *Tag) error {
 
 // PutTag updates an existing Tag and Alert
 func (client *InsightClient) PutTag(tag *Tag) error {
+	return client.PutTagContext(context.Background(), tag)
+}
+
+// PutTagContext updates an existing Tag and Alert using the provided context
+func (client *InsightClient) PutTagContext(ctx context.Context, tag *Tag) error {
 	if tag.UserData == nil {
 		tag.UserData = make(map[string]string)
 	}
@@ -93,7 +137,7 @@ func (client *InsightClient) PutTag(tag *Tag) error {
 	if err != nil {
 		return err
 	}
-	resp, err := client.put(endpoint, tagRequest)
+	resp, err := client.putWithContext(ctx, endpoint, tagRequest)
 	if err != nil {
 		return err
 	}
@@ -106,11 +150,16 @@ func (client *InsightClient) PutTag(tag *Tag) error {
 
 // DeleteTag deletes a specific Tag from an account.
 func (client *InsightClient) DeleteTag(tagId string) error {
+	return client.DeleteTagContext(context.Background(), tagId)
+}
+
+// DeleteTagContext deletes a specific Tag from an account using the provided context
+func (client *InsightClient) DeleteTagContext(ctx context.Context, tagId string) error {
 	endpoint, err := client.getTagEndpoint(tagId)
 	if err != nil {
 		return err
 	}
-	return client.delete(endpoint)
+	return client.deleteWithContext(ctx, endpoint)
 }
 
 // getTagEndPoint returns the rest end point to retrieve an individual tag
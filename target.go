@@ -1,6 +1,7 @@
 package insight_goclient
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -47,21 +48,54 @@ type TargetRequest struct {
 
 // GetTargets gets details of a list of all Targets
 func (client *InsightClient) GetTargets() ([]*Target, error) {
-	var targets Targets
-	if err := client.get(TARGETS_PATH, &targets); err != nil {
+	return client.GetTargetsContext(context.Background())
+}
+
+// GetTargetsContext gets details of a list of all Targets using the provided context
+func (client *InsightClient) GetTargetsContext(ctx context.Context) ([]*Target, error) {
+	var result []*Target
+	err := client.ForEachTargetContext(ctx, func(target *Target) error {
+		result = append(result, target)
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	return targets.Targets, nil
+	return result, nil
+}
+
+// ForEachTarget calls fn for every Target of the account, fetching them page by page
+func (client *InsightClient) ForEachTarget(fn func(target *Target) error) error {
+	return client.ForEachTargetContext(context.Background(), fn)
+}
+
+// ForEachTargetContext calls fn for every Target of the account, fetching them page by page using the provided
+// context. Iteration stops at the first error returned by fn; return ErrStopIteration to stop without failing
+func (client *InsightClient) ForEachTargetContext(ctx context.Context, fn func(target *Target) error) error {
+	var targets Targets
+	return client.getPages(ctx, TARGETS_PATH, &targets, func() error {
+		for _, target := range targets.Targets {
+			if err := fn(target); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // GetTarget gets a specific Target from an account
 func (client *InsightClient) GetTarget(targetId string) (*Target, error) {
+	return client.GetTargetContext(context.Background(), targetId)
+}
+
+// GetTargetContext gets a specific Target from an account using the provided context
+func (client *InsightClient) GetTargetContext(ctx context.Context, targetId string) (*Target, error) {
 	var targetRequest TargetRequest
 	endpoint, err := client.getTargetEndpoint(targetId)
 	if err != nil {
 		return nil, err
 	}
-	if err := client.get(endpoint, &targetRequest); err != nil {
+	if err := client.getWithContext(ctx, endpoint, &targetRequest); err != nil {
 		return nil, err
 	}
 	return targetRequest.Target, nil
@@ -69,8 +103,13 @@ func (client *InsightClient) GetTarget(targetId string) (*Target, error) {
 
 // GetTarget gets a specific Target from an account by name
 func (client *InsightClient) GetTargetsByName(name string) ([]*Target, error) {
+	return client.GetTargetsByNameContext(context.Background(), name)
+}
+
+// GetTargetsByNameContext gets the Targets from an account matching name using the provided context
+func (client *InsightClient) GetTargetsByNameContext(ctx context.Context, name string) ([]*Target, error) {
 	var result []*Target
-	targets, err := client.GetTargets()
+	targets, err := client.GetTargetsContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -84,11 +123,16 @@ func (client *InsightClient) GetTargetsByName(name string) ([]*Target, error) {
 
 // PostTag creates a new Target
 func (client *InsightClient) PostTarget(target *Target) error {
+	return client.PostTargetContext(context.Background(), target)
+}
+
+// PostTargetContext creates a new Target using the provided context
+func (client *InsightClient) PostTargetContext(ctx context.Context, target *Target) error {
 	if target.UserData == nil {
 		target.UserData = make(map[string]string)
 	}
 	targetRequest := TargetRequest{target}
-	resp, err := client.post(TARGETS_PATH, targetRequest)
+	resp, err := client.postWithContext(ctx, TARGETS_PATH, targetRequest)
 	if err != nil {
 		return err
 	}
@@ -101,6 +145,11 @@ func (client *InsightClient) PostTarget(target *Target) error {
 
 // PutTag updates an existing Target
 func (client *InsightClient) PutTarget(target *Target) error {
+	return client.PutTargetContext(context.Background(), target)
+}
+
+// PutTargetContext updates an existing Target using the provided context
+func (client *InsightClient) PutTargetContext(ctx context.Context, target *Target) error {
 	if target.UserData == nil {
 		target.UserData = make(map[string]string)
 	}
@@ -109,7 +158,7 @@ func (client *InsightClient) PutTarget(target *Target) error {
 	if err != nil {
 		return err
 	}
-	resp, err := client.put(endpoint, targetRequest)
+	resp, err := client.putWithContext(ctx, endpoint, targetRequest)
 	if err != nil {
 		return err
 	}
@@ -122,11 +171,16 @@ func (client *InsightClient) PutTarget(target *Target) error {
 
 // DeleteTag deletes a specific Target from an account.
 func (client *InsightClient) DeleteTarget(targetId string) error {
+	return client.DeleteTargetContext(context.Background(), targetId)
+}
+
+// DeleteTargetContext deletes a specific Target from an account using the provided context
+func (client *InsightClient) DeleteTargetContext(ctx context.Context, targetId string) error {
 	endpoint, err := client.getTargetEndpoint(targetId)
 	if err != nil {
 		return err
 	}
-	return client.delete(endpoint)
+	return client.deleteWithContext(ctx, endpoint)
 }
 
 func (client *InsightClient) getTargetEndpoint(targetId string) (string, error) {
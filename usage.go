@@ -0,0 +1,175 @@
+package insight_goclient
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+)
+
+const (
+	USAGE_PATH        = "/usage/organizations"
+	LOGS_USAGE_PATH   = "/usage/organizations/logs"
+	USAGE_DATE_FORMAT = "2006-01-02"
+)
+
+// The Usage resource allows you to retrieve the volume of data ingested by your account. The following operations
+// are supported:
+// - Get the daily usage of the whole account
+// - Get the daily usage of every Log
+// - Get the daily usage of a specific Log
+// - Get the daily usage of every Log of a Log Set
+
+// Usage represents the volume of data, in bytes, ingested over a date range
+type Usage struct {
+	From       string        `json:"from"`
+	To         string        `json:"to"`
+	Usage      int64         `json:"usage"`
+	DailyUsage []*DailyUsage `json:"daily_usage"`
+}
+
+// LogUsage represents the volume of data, in bytes, ingested by a Log over a date range
+type LogUsage struct {
+	Id         string        `json:"id"`
+	Name       string        `json:"name,omitempty"`
+	Usage      int64         `json:"usage"`
+	DailyUsage []*DailyUsage `json:"daily_usage"`
+}
+
+// DailyUsage represents the volume of data, in bytes, ingested on a given day (YYYY-MM-DD)
+type DailyUsage struct {
+	Day   string `json:"day"`
+	Usage int64  `json:"usage"`
+}
+
+type LogsUsage struct {
+	From string      `json:"from"`
+	To   string      `json:"to"`
+	Logs []*LogUsage `json:"logs"`
+}
+
+type UsageRequest struct {
+	Usage *Usage `json:"usage"`
+}
+
+type LogsUsageRequest struct {
+	Usage *LogsUsage `json:"usage"`
+}
+
+type LogUsageRequest struct {
+	Usage *LogUsage `json:"usage"`
+}
+
+// GetUsage gets the daily usage of the whole account between from and to (inclusive)
+func (client *InsightClient) GetUsage(from, to time.Time) (*Usage, error) {
+	return client.GetUsageContext(context.Background(), from, to)
+}
+
+// GetUsageContext gets the daily usage of the whole account between from and to (inclusive) using the provided
+// context
+func (client *InsightClient) GetUsageContext(ctx context.Context, from, to time.Time) (*Usage, error) {
+	var usageRequest UsageRequest
+	endpoint, err := getUsageEndpoint(USAGE_PATH, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.getWithContext(ctx, endpoint, &usageRequest); err != nil {
+		return nil, err
+	}
+	return usageRequest.Usage, nil
+}
+
+// GetLogsUsage gets the daily usage of every Log between from and to (inclusive), keyed by Log Id
+func (client *InsightClient) GetLogsUsage(from, to time.Time) (map[string]*LogUsage, error) {
+	return client.GetLogsUsageContext(context.Background(), from, to)
+}
+
+// GetLogsUsageContext gets the daily usage of every Log between from and to (inclusive), keyed by Log Id, using the
+// provided context
+func (client *InsightClient) GetLogsUsageContext(ctx context.Context, from, to time.Time) (map[string]*LogUsage, error) {
+	var logsUsageRequest LogsUsageRequest
+	endpoint, err := getUsageEndpoint(LOGS_USAGE_PATH, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.getWithContext(ctx, endpoint, &logsUsageRequest); err != nil {
+		return nil, err
+	}
+	result := map[string]*LogUsage{}
+	if logsUsageRequest.Usage != nil {
+		for _, logUsage := range logsUsageRequest.Usage.Logs {
+			result[logUsage.Id] = logUsage
+		}
+	}
+	return result, nil
+}
+
+// GetLogUsage gets the daily usage of a specific Log between from and to (inclusive)
+func (client *InsightClient) GetLogUsage(logId string, from, to time.Time) (*LogUsage, error) {
+	return client.GetLogUsageContext(context.Background(), logId, from, to)
+}
+
+// GetLogUsageContext gets the daily usage of a specific Log between from and to (inclusive) using the provided
+// context
+func (client *InsightClient) GetLogUsageContext(ctx context.Context, logId string, from, to time.Time) (*LogUsage, error) {
+	if logId == "" {
+		return nil, fmt.Errorf("logId input parameter is mandatory")
+	}
+	var logUsageRequest LogUsageRequest
+	endpoint, err := getUsageEndpoint(fmt.Sprintf("%s/%s", LOGS_USAGE_PATH, logId), from, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.getWithContext(ctx, endpoint, &logUsageRequest); err != nil {
+		return nil, err
+	}
+	return logUsageRequest.Usage, nil
+}
+
+// GetLogsetUsage gets the daily usage of every Log of the Log Set between from and to (inclusive), summed per day
+func (client *InsightClient) GetLogsetUsage(logset *Logset, from, to time.Time) (*Usage, error) {
+	return client.GetLogsetUsageContext(context.Background(), logset, from, to)
+}
+
+// GetLogsetUsageContext gets the daily usage of every Log of the Log Set between from and to (inclusive), summed per
+// day, using the provided context
+func (client *InsightClient) GetLogsetUsageContext(ctx context.Context, logset *Logset, from, to time.Time) (*Usage, error) {
+	if logset == nil {
+		return nil, fmt.Errorf("logset input parameter is mandatory")
+	}
+	logsUsage, err := client.GetLogsUsageContext(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	usage := &Usage{From: from.Format(USAGE_DATE_FORMAT), To: to.Format(USAGE_DATE_FORMAT)}
+	dailyUsage := map[string]int64{}
+	for _, logInfo := range logset.LogsInfo {
+		logUsage, ok := logsUsage[logInfo.Id]
+		if !ok {
+			continue
+		}
+		usage.Usage += logUsage.Usage
+		for _, day := range logUsage.DailyUsage {
+			dailyUsage[day.Day] += day.Usage
+		}
+	}
+	for day, bytes := range dailyUsage {
+		usage.DailyUsage = append(usage.DailyUsage, &DailyUsage{Day: day, Usage: bytes})
+	}
+	sort.Slice(usage.DailyUsage, func(i, j int) bool {
+		return usage.DailyUsage[i].Day < usage.DailyUsage[j].Day
+	})
+	return usage, nil
+}
+
+// getUsageEndpoint returns the rest end point to retrieve the usage between from and to
+func getUsageEndpoint(path string, from, to time.Time) (string, error) {
+	if to.Before(from) {
+		return "", fmt.Errorf("from date %s must not be after to date %s", from.Format(USAGE_DATE_FORMAT), to.Format(USAGE_DATE_FORMAT))
+	}
+	query := url.Values{}
+	query.Set("from", from.Format(USAGE_DATE_FORMAT))
+	query.Set("to", to.Format(USAGE_DATE_FORMAT))
+	return fmt.Sprintf("%s?%s", path, query.Encode()), nil
+}
@@ -0,0 +1,95 @@
+package insight_goclient
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var (
+	usageFrom = time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)
+	usageTo   = time.Date(2019, 5, 2, 0, 0, 0, 0, time.UTC)
+)
+
+func TestUsage_GetUsage(t *testing.T) {
+	expectedUsage := &Usage{
+		From:  "2019-05-01",
+		To:    "2019-05-02",
+		Usage: 300,
+		DailyUsage: []*DailyUsage{
+			{Day: "2019-05-01", Usage: 100},
+			{Day: "2019-05-02", Usage: 200},
+		},
+	}
+
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/usage/organizations", nil, http.StatusOK, UsageRequest{expectedUsage})
+	client := getTestClient(requestMatcher)
+	returnedUsage, err := client.GetUsage(usageFrom, usageTo)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedUsage, returnedUsage)
+
+	_, err = client.GetUsage(usageTo, usageFrom)
+	assert.NotNil(t, err)
+}
+
+func TestUsage_GetLogUsage(t *testing.T) {
+	expectedUsage := &LogUsage{
+		Id:         "log-uuid",
+		Usage:      100,
+		DailyUsage: []*DailyUsage{{Day: "2019-05-01", Usage: 100}},
+	}
+
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/usage/organizations/logs/log-uuid", nil, http.StatusOK, LogUsageRequest{expectedUsage})
+	client := getTestClient(requestMatcher)
+	returnedUsage, err := client.GetLogUsage("log-uuid", usageFrom, usageTo)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedUsage, returnedUsage)
+
+	_, err = client.GetLogUsage("", usageFrom, usageTo)
+	assert.NotNil(t, err)
+}
+
+func getTestLogsUsage() *LogsUsage {
+	return &LogsUsage{
+		From: "2019-05-01",
+		To:   "2019-05-02",
+		Logs: []*LogUsage{
+			{Id: "log-1", Usage: 30, DailyUsage: []*DailyUsage{{Day: "2019-05-01", Usage: 10}, {Day: "2019-05-02", Usage: 20}}},
+			{Id: "log-2", Usage: 7, DailyUsage: []*DailyUsage{{Day: "2019-05-02", Usage: 7}}},
+			{Id: "log-3", Usage: 1000, DailyUsage: []*DailyUsage{{Day: "2019-05-01", Usage: 1000}}},
+		},
+	}
+}
+
+func TestUsage_GetLogsUsage(t *testing.T) {
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/usage/organizations/logs", nil, http.StatusOK, LogsUsageRequest{getTestLogsUsage()})
+	client := getTestClient(requestMatcher)
+	logsUsage, err := client.GetLogsUsage(usageFrom, usageTo)
+	assert.Nil(t, err)
+	assert.Len(t, logsUsage, 3)
+	assert.Equal(t, int64(7), logsUsage["log-2"].Usage)
+}
+
+func TestUsage_GetLogsetUsage(t *testing.T) {
+	logset := &Logset{Id: "log-set-uuid", LogsInfo: []*Info{{Id: "log-1"}, {Id: "log-2"}, {Id: "log-without-usage"}}}
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/usage/organizations/logs", nil, http.StatusOK, LogsUsageRequest{getTestLogsUsage()})
+	client := getTestClient(requestMatcher)
+	usage, err := client.GetLogsetUsage(logset, usageFrom, usageTo)
+	assert.Nil(t, err)
+	assert.EqualValues(t, &Usage{
+		From:  "2019-05-01",
+		To:    "2019-05-02",
+		Usage: 37,
+		DailyUsage: []*DailyUsage{
+			{Day: "2019-05-01", Usage: 10},
+			{Day: "2019-05-02", Usage: 27},
+		},
+	}, usage)
+}
+
+func TestUsage_GetUsageEndpoint(t *testing.T) {
+	endpoint, err := getUsageEndpoint(USAGE_PATH, usageFrom, usageTo)
+	assert.Nil(t, err)
+	assert.Equal(t, "/usage/organizations?from=2019-05-01&to=2019-05-02", endpoint)
+}
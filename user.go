@@ -0,0 +1,193 @@
+package insight_goclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	USERS_PATH = "/management/users"
+)
+
+// The Users resource allows you to manage who has access to your account. The following operations are supported:
+// - Get details of an existing User
+// - Get details of a list of all Users
+// - Invite a new User
+// - Change the Roles assigned to an existing User
+// - Remove a User
+
+// User represents the entity used to get an existing user from the insight API
+type User struct {
+	Id        string  `json:"id,omitempty"`
+	Email     string  `json:"email"`
+	FirstName string  `json:"first_name,omitempty"`
+	LastName  string  `json:"last_name,omitempty"`
+	Roles     []*Info `json:"roles,omitempty"`
+}
+
+type Users struct {
+	Users []*User `json:"users"`
+}
+
+type UserRequest struct {
+	User *User `json:"user"`
+}
+
+type UserRolesRequest struct {
+	Roles []*Info `json:"roles"`
+}
+
+// GetUsers gets details of a list of all Users
+func (client *InsightClient) GetUsers() ([]*User, error) {
+	return client.GetUsersContext(context.Background())
+}
+
+// GetUsersContext gets details of a list of all Users using the provided context
+func (client *InsightClient) GetUsersContext(ctx context.Context) ([]*User, error) {
+	var result []*User
+	err := client.ForEachUserContext(ctx, func(user *User) error {
+		result = append(result, user)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ForEachUser calls fn for every User of the account, fetching them page by page
+func (client *InsightClient) ForEachUser(fn func(user *User) error) error {
+	return client.ForEachUserContext(context.Background(), fn)
+}
+
+// ForEachUserContext calls fn for every User of the account, fetching them page by page using the provided
+// context. Iteration stops at the first error returned by fn; return ErrStopIteration to stop without failing
+func (client *InsightClient) ForEachUserContext(ctx context.Context, fn func(user *User) error) error {
+	var users Users
+	return client.getPages(ctx, USERS_PATH, &users, func() error {
+		for _, user := range users.Users {
+			if err := fn(user); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetUser gets a specific User from an account
+func (client *InsightClient) GetUser(userId string) (*User, error) {
+	return client.GetUserContext(context.Background(), userId)
+}
+
+// GetUserContext gets a specific User from an account using the provided context
+func (client *InsightClient) GetUserContext(ctx context.Context, userId string) (*User, error) {
+	var userRequest UserRequest
+	endpoint, err := client.getUserEndpoint(userId)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.getWithContext(ctx, endpoint, &userRequest); err != nil {
+		return nil, err
+	}
+	return userRequest.User, nil
+}
+
+// GetUserByEmail gets the User from an account with the given email address, compared case insensitively
+func (client *InsightClient) GetUserByEmail(email string) (*User, error) {
+	return client.GetUserByEmailContext(context.Background(), email)
+}
+
+// GetUserByEmailContext gets the User from an account with the given email address, compared case insensitively,
+// using the provided context
+func (client *InsightClient) GetUserByEmailContext(ctx context.Context, email string) (*User, error) {
+	if email == "" {
+		return nil, fmt.Errorf("email input parameter is mandatory")
+	}
+	var result *User
+	err := client.ForEachUserContext(ctx, func(user *User) error {
+		if strings.EqualFold(user.Email, email) {
+			result = user
+			return ErrStopIteration
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("No user with email %s exists", email)
+	}
+	return result, nil
+}
+
+// InviteUser invites a new User to the account with the Roles set on user
+func (client *InsightClient) InviteUser(user *User) error {
+	return client.InviteUserContext(context.Background(), user)
+}
+
+// InviteUserContext invites a new User to the account with the Roles set on user using the provided context
+func (client *InsightClient) InviteUserContext(ctx context.Context, user *User) error {
+	if user == nil || user.Email == "" {
+		return fmt.Errorf("user input parameter with a valid Email is mandatory")
+	}
+	userRequest := UserRequest{user}
+	resp, err := client.postWithContext(ctx, USERS_PATH, userRequest)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(resp, &userRequest)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetUserRoles replaces the Roles assigned to a specific User with the given ones
+func (client *InsightClient) SetUserRoles(userId string, roleIds ...string) (*User, error) {
+	return client.SetUserRolesContext(context.Background(), userId, roleIds...)
+}
+
+// SetUserRolesContext replaces the Roles assigned to a specific User with the given ones using the provided context
+func (client *InsightClient) SetUserRolesContext(ctx context.Context, userId string, roleIds ...string) (*User, error) {
+	endpoint, err := client.getUserEndpoint(userId)
+	if err != nil {
+		return nil, err
+	}
+	rolesRequest := UserRolesRequest{Roles: []*Info{}}
+	for _, roleId := range roleIds {
+		rolesRequest.Roles = append(rolesRequest.Roles, &Info{Id: roleId})
+	}
+	resp, err := client.putWithContext(ctx, fmt.Sprintf("%s/roles", endpoint), rolesRequest)
+	if err != nil {
+		return nil, err
+	}
+	var userRequest UserRequest
+	if err := json.Unmarshal(resp, &userRequest); err != nil {
+		return nil, err
+	}
+	return userRequest.User, nil
+}
+
+// DeleteUser removes a specific User from an account.
+func (client *InsightClient) DeleteUser(userId string) error {
+	return client.DeleteUserContext(context.Background(), userId)
+}
+
+// DeleteUserContext removes a specific User from an account using the provided context
+func (client *InsightClient) DeleteUserContext(ctx context.Context, userId string) error {
+	endpoint, err := client.getUserEndpoint(userId)
+	if err != nil {
+		return err
+	}
+	return client.deleteWithContext(ctx, endpoint)
+}
+
+func (client *InsightClient) getUserEndpoint(userId string) (string, error) {
+	if userId == "" {
+		return "", fmt.Errorf("userId input parameter is mandatory")
+	} else {
+		return fmt.Sprintf("%s/%s", USERS_PATH, userId), nil
+	}
+}
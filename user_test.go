@@ -0,0 +1,97 @@
+package insight_goclient
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func getTestUser() *User {
+	return &User{
+		Id:        "user-uuid",
+		Email:     "jane.doe@example.com",
+		FirstName: "Jane",
+		LastName:  "Doe",
+		Roles:     []*Info{{Id: "read-only-role-uuid", Name: "Read Only"}},
+	}
+}
+
+func TestUsers_GetUsers(t *testing.T) {
+	expectedUsers := []*User{getTestUser()}
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/management/users", nil, http.StatusOK, Users{expectedUsers})
+	client := getTestClient(requestMatcher)
+	returnedUsers, err := client.GetUsers()
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedUsers, returnedUsers)
+}
+
+func TestUsers_GetUser(t *testing.T) {
+	expectedUser := getTestUser()
+	url := fmt.Sprintf("/management/users/%s", expectedUser.Id)
+	requestMatcher := NewRequestMatcher(http.MethodGet, url, nil, http.StatusOK, UserRequest{expectedUser})
+	client := getTestClient(requestMatcher)
+	returnedUser, err := client.GetUser(expectedUser.Id)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedUser, returnedUser)
+}
+
+func TestUsers_GetUserErrorsIfUserIdIsEmpty(t *testing.T) {
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/management/users/", nil, http.StatusOK, UserRequest{&User{}})
+	client := getTestClient(requestMatcher)
+	_, err := client.GetUser("")
+	assert.NotNil(t, err)
+	assert.Error(t, err, "userId input parameter is mandatory")
+}
+
+func TestUsers_GetUserByEmail(t *testing.T) {
+	expectedUser := getTestUser()
+	users := []*User{{Id: "other-uuid", Email: "john@example.com"}, expectedUser}
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/management/users", nil, http.StatusOK, Users{users})
+	client := getTestClient(requestMatcher)
+	returnedUser, err := client.GetUserByEmail("Jane.Doe@Example.com")
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedUser, returnedUser)
+
+	_, err = client.GetUserByEmail("missing@example.com")
+	assert.NotNil(t, err)
+}
+
+func TestUsers_InviteUser(t *testing.T) {
+	p := &User{
+		Email: "jane.doe@example.com",
+		Roles: []*Info{{Id: "read-only-role-uuid"}},
+	}
+
+	expectedUser := getTestUser()
+
+	requestMatcher := NewRequestMatcher(http.MethodPost, "/management/users", UserRequest{p}, http.StatusCreated, UserRequest{expectedUser})
+	client := getTestClient(requestMatcher)
+	err := client.InviteUser(p)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedUser, p)
+
+	err = client.InviteUser(&User{})
+	assert.NotNil(t, err)
+}
+
+func TestUsers_SetUserRoles(t *testing.T) {
+	expectedUser := getTestUser()
+	expectedUser.Roles = []*Info{{Id: "admin-role-uuid", Name: "Administrator"}}
+	url := fmt.Sprintf("/management/users/%s/roles", expectedUser.Id)
+	payload := UserRolesRequest{[]*Info{{Id: "admin-role-uuid"}}}
+	requestMatcher := NewRequestMatcher(http.MethodPut, url, payload, http.StatusOK, UserRequest{expectedUser})
+	client := getTestClient(requestMatcher)
+	returnedUser, err := client.SetUserRoles(expectedUser.Id, "admin-role-uuid")
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedUser, returnedUser)
+}
+
+func TestUsers_DeleteUser(t *testing.T) {
+	userId := "user-uuid"
+	url := fmt.Sprintf("/management/users/%s", userId)
+	requestMatcher := NewRequestMatcher(http.MethodDelete, url, nil, http.StatusNoContent, nil)
+	client := getTestClient(requestMatcher)
+	err := client.DeleteUser(userId)
+	assert.Nil(t, err)
+}
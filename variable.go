@@ -0,0 +1,174 @@
+package insight_goclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	VARIABLES_PATH = "/query/variables"
+)
+
+// The Variables resource allows you to interact with the LEQL Variables of your account. The following operations
+// are supported:
+// - Get details of an existing Variable
+// - Get details of a list of all Variables
+// - Create a new Variable
+// - Update an existing Variable
+// - Delete a Variable
+
+// Variable represents the entity used to get an existing LEQL variable from the insight API
+type Variable struct {
+	Id          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+type Variables struct {
+	Variables []*Variable `json:"variables"`
+}
+
+type VariableRequest struct {
+	Variable *Variable `json:"variable"`
+}
+
+// GetVariables gets details of a list of all Variables
+func (client *InsightClient) GetVariables() ([]*Variable, error) {
+	return client.GetVariablesContext(context.Background())
+}
+
+// GetVariablesContext gets details of a list of all Variables using the provided context
+func (client *InsightClient) GetVariablesContext(ctx context.Context) ([]*Variable, error) {
+	var result []*Variable
+	err := client.ForEachVariableContext(ctx, func(variable *Variable) error {
+		result = append(result, variable)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ForEachVariable calls fn for every Variable of the account, fetching them page by page
+func (client *InsightClient) ForEachVariable(fn func(variable *Variable) error) error {
+	return client.ForEachVariableContext(context.Background(), fn)
+}
+
+// ForEachVariableContext calls fn for every Variable of the account, fetching them page by page using the provided
+// context. Iteration stops at the first error returned by fn; return ErrStopIteration to stop without failing
+func (client *InsightClient) ForEachVariableContext(ctx context.Context, fn func(variable *Variable) error) error {
+	var variables Variables
+	return client.getPages(ctx, VARIABLES_PATH, &variables, func() error {
+		for _, variable := range variables.Variables {
+			if err := fn(variable); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetVariable gets a specific Variable from an account
+func (client *InsightClient) GetVariable(variableId string) (*Variable, error) {
+	return client.GetVariableContext(context.Background(), variableId)
+}
+
+// GetVariableContext gets a specific Variable from an account using the provided context
+func (client *InsightClient) GetVariableContext(ctx context.Context, variableId string) (*Variable, error) {
+	var variableRequest VariableRequest
+	endpoint, err := client.getVariableEndpoint(variableId)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.getWithContext(ctx, endpoint, &variableRequest); err != nil {
+		return nil, err
+	}
+	return variableRequest.Variable, nil
+}
+
+// GetVariablesByName gets the Variables from an account matching name
+func (client *InsightClient) GetVariablesByName(name string) ([]*Variable, error) {
+	return client.GetVariablesByNameContext(context.Background(), name)
+}
+
+// GetVariablesByNameContext gets the Variables from an account matching name using the provided context
+func (client *InsightClient) GetVariablesByNameContext(ctx context.Context, name string) ([]*Variable, error) {
+	var result []*Variable
+	variables, err := client.GetVariablesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, variable := range variables {
+		if variable.Name == name {
+			result = append(result, variable)
+		}
+	}
+	return result, nil
+}
+
+// PostVariable creates a new Variable
+func (client *InsightClient) PostVariable(variable *Variable) error {
+	return client.PostVariableContext(context.Background(), variable)
+}
+
+// PostVariableContext creates a new Variable using the provided context
+func (client *InsightClient) PostVariableContext(ctx context.Context, variable *Variable) error {
+	variableRequest := VariableRequest{variable}
+	resp, err := client.postWithContext(ctx, VARIABLES_PATH, variableRequest)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(resp, &variableRequest)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// PutVariable updates an existing Variable
+func (client *InsightClient) PutVariable(variable *Variable) error {
+	return client.PutVariableContext(context.Background(), variable)
+}
+
+// PutVariableContext updates an existing Variable using the provided context
+func (client *InsightClient) PutVariableContext(ctx context.Context, variable *Variable) error {
+	variableRequest := VariableRequest{variable}
+	endpoint, err := client.getVariableEndpoint(variable.Id)
+	if err != nil {
+		return err
+	}
+	resp, err := client.putWithContext(ctx, endpoint, variableRequest)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(resp, &variableRequest)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteVariable deletes a specific Variable from an account.
+func (client *InsightClient) DeleteVariable(variableId string) error {
+	return client.DeleteVariableContext(context.Background(), variableId)
+}
+
+// DeleteVariableContext deletes a specific Variable from an account using the provided context
+func (client *InsightClient) DeleteVariableContext(ctx context.Context, variableId string) error {
+	endpoint, err := client.getVariableEndpoint(variableId)
+	if err != nil {
+		return err
+	}
+	return client.deleteWithContext(ctx, endpoint)
+}
+
+func (client *InsightClient) getVariableEndpoint(variableId string) (string, error) {
+	if variableId == "" {
+		return "", fmt.Errorf("variableId input parameter is mandatory")
+	} else {
+		return fmt.Sprintf("%s/%s", VARIABLES_PATH, variableId), nil
+	}
+}
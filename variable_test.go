@@ -0,0 +1,103 @@
+package insight_goclient
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestVariables_GetVariables(t *testing.T) {
+	expectedVariables := []*Variable{
+		{
+			Id:          "variable-uuid",
+			Name:        "internal_ips",
+			Value:       "/10\\.0\\..*/",
+			Description: "Internal network",
+		},
+	}
+
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/query/variables", nil, http.StatusOK, Variables{expectedVariables})
+	client := getTestClient(requestMatcher)
+	returnedVariables, err := client.GetVariables()
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedVariables, returnedVariables)
+}
+
+func TestVariables_GetVariable(t *testing.T) {
+	expectedVariable := &Variable{
+		Id:    "variable-uuid",
+		Name:  "internal_ips",
+		Value: "/10\\.0\\..*/",
+	}
+
+	url := fmt.Sprintf("/query/variables/%s", expectedVariable.Id)
+	requestMatcher := NewRequestMatcher(http.MethodGet, url, nil, http.StatusOK, VariableRequest{expectedVariable})
+	client := getTestClient(requestMatcher)
+	returnedVariable, err := client.GetVariable(expectedVariable.Id)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedVariable, returnedVariable)
+}
+
+func TestVariables_GetVariableErrorsIfVariableIdIsEmpty(t *testing.T) {
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/query/variables/", nil, http.StatusOK, VariableRequest{&Variable{}})
+	client := getTestClient(requestMatcher)
+	_, err := client.GetVariable("")
+	assert.NotNil(t, err)
+	assert.Error(t, err, "variableId input parameter is mandatory")
+}
+
+func TestVariables_GetVariablesByName(t *testing.T) {
+	variables := []*Variable{
+		{Id: "variable-1", Name: "internal_ips", Value: "a"},
+		{Id: "variable-2", Name: "admins", Value: "b"},
+	}
+
+	requestMatcher := NewRequestMatcher(http.MethodGet, "/query/variables", nil, http.StatusOK, Variables{variables})
+	client := getTestClient(requestMatcher)
+	returnedVariables, err := client.GetVariablesByName("admins")
+	assert.Nil(t, err)
+	assert.EqualValues(t, []*Variable{variables[1]}, returnedVariables)
+}
+
+func TestVariables_PostVariable(t *testing.T) {
+	p := &Variable{
+		Name:  "admins",
+		Value: "/(alice|bob)/",
+	}
+
+	expectedVariable := &Variable{
+		Id:    "variable-uuid",
+		Name:  p.Name,
+		Value: p.Value,
+	}
+
+	requestMatcher := NewRequestMatcher(http.MethodPost, "/query/variables", VariableRequest{p}, http.StatusCreated, VariableRequest{expectedVariable})
+	client := getTestClient(requestMatcher)
+	err := client.PostVariable(p)
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedVariable, p)
+}
+
+func TestVariables_PutVariable(t *testing.T) {
+	p := &Variable{
+		Id:    "variable-uuid",
+		Name:  "admins",
+		Value: "/(alice|bob|carol)/",
+	}
+
+	url := fmt.Sprintf("/query/variables/%s", p.Id)
+	requestMatcher := NewRequestMatcher(http.MethodPut, url, VariableRequest{p}, http.StatusOK, VariableRequest{p})
+	client := getTestClient(requestMatcher)
+	err := client.PutVariable(p)
+	assert.Nil(t, err)
+}
+
+func TestVariables_DeleteVariable(t *testing.T) {
+	variableId := "variable-uuid"
+	url := fmt.Sprintf("/query/variables/%s", variableId)
+	requestMatcher := NewRequestMatcher(http.MethodDelete, url, nil, http.StatusNoContent, nil)
+	client := getTestClient(requestMatcher)
+	err := client.DeleteVariable(variableId)
+	assert.Nil(t, err)
+}